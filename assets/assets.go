@@ -1,6 +1,9 @@
 package assets
 
 import (
+	"bytes"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"qlova.org/seed"
@@ -18,14 +21,32 @@ func init() {
 
 func init() {
 	client.RegisterRenderer(func(c seed.Seed) []byte {
-		return []byte(`
+		var b bytes.Buffer
+
+		b.WriteString(`
 			seed.asset = function(src) {
 				if (!src.startsWith("/") && !src.startsWith("http")) {
-					return "/assets/"+src;
+					src = "/assets/"+src;
+				}
+				if (src in seed.asset.fingerprints) {
+					return seed.asset.fingerprints[src];
 				}
 				return src;
 			};
+			seed.asset.fingerprints = {`)
+
+		//Deterministic render.
+		for i, src := range Fingerprints() {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, `%v: %v`, strconv.Quote(src), strconv.Quote(Fingerprinted(src)))
+		}
+
+		b.WriteString(`};
 		`)
+
+		return b.Bytes()
 	})
 }
 
@@ -0,0 +1,68 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"sort"
+	"strings"
+)
+
+//fingerprints maps original paths to their content-hashed paths.
+var fingerprints = make(map[string]string)
+
+//originals maps content-hashed paths back to their original paths.
+var originals = make(map[string]string)
+
+//Hash returns the content hash of the given data, this is suitable for use as an ETag.
+func Hash(data []byte) string {
+	var sum = sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+//Fingerprint registers the content of the asset at the given path and returns
+//a content-hashed path that can be cached indefinitely by clients.
+//ie. "/swiper.js" becomes "/swiper.0123456789abcdef.js"
+func Fingerprint(src string, content []byte) string {
+	if src == "" || strings.HasPrefix(src, "http") {
+		return src
+	}
+
+	var ext = path.Ext(src)
+	var hashed = strings.TrimSuffix(src, ext) + "." + Hash(content) + ext
+
+	if old, ok := fingerprints[src]; ok {
+		delete(originals, old)
+	}
+
+	fingerprints[src] = hashed
+	originals[hashed] = src
+
+	return hashed
+}
+
+//Fingerprinted returns the content-hashed path of a previously fingerprinted asset.
+//If the asset has not been fingerprinted, then src is returned as is.
+func Fingerprinted(src string) string {
+	if hashed, ok := fingerprints[src]; ok {
+		return hashed
+	}
+	return src
+}
+
+//Original returns the original path of the given content-hashed path and true.
+//Returns false if hashed is not a known content-hashed path.
+func Original(hashed string) (string, bool) {
+	src, ok := originals[hashed]
+	return src, ok
+}
+
+//Fingerprints returns the original paths of all fingerprinted assets, sorted.
+func Fingerprints() []string {
+	var keys = make([]string, 0, len(fingerprints))
+	for key := range fingerprints {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path"
@@ -21,6 +22,7 @@ import (
 	"strconv"
 	"unicode/utf8"
 
+	"github.com/andybalholm/brotli"
 	"github.com/tdewolff/minify"
 	"github.com/tdewolff/minify/css"
 	"github.com/tdewolff/minify/html"
@@ -76,6 +78,14 @@ func init() {
 		gw.Close()
 		return nil
 	})
+	mini.AddFunc("encoding/br", func(m *minify.M, w io.Writer, r io.Reader, _ map[string]string) error {
+		bw := brotli.NewWriterLevel(w, brotli.BestCompression)
+		_, err := io.Copy(bw, r)
+		if err != nil {
+			return fmt.Errorf("could not brotli stream: %w", err)
+		}
+		return bw.Close()
+	})
 	/*mini.AddFunc("image/png", func(m *minify.M, w io.Writer, r io.Reader, _ map[string]string) error {
 		img, _, err := image.Decode(r)
 		if err != nil {
@@ -128,7 +138,13 @@ func embedFile(name string, w *os.File, r io.Reader) error {
 		reader = bufio.NewReader(r)
 	}
 
-	reader = bufio.NewReader(mini.Reader("encoding/gzip", reader))
+	//Minify once, then compress the result with each supported encoding.
+	minified, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("could not read file: %w", err)
+	}
+
+	reader = bufio.NewReader(mini.Reader("encoding/gzip", bytes.NewReader(minified)))
 
 	writer = bufio.NewWriter(w)
 
@@ -146,10 +162,36 @@ func embedFile(name string, w *os.File, r io.Reader) error {
 		}
 	}
 
-	for {
-		reader := reader.(*bufio.Reader)
-		writer := writer.(*bufio.Writer)
+	if err := writeEscaped(writer.(*bufio.Writer), reader.(*bufio.Reader)); err != nil {
+		return err
+	}
+
+	if _, err := writer.(*bufio.Writer).WriteString(`"))` + "\n"); err != nil {
+		return fmt.Errorf("could not write file: %w", err)
+	}
+
+	if _, err := writer.(*bufio.Writer).WriteString(fmt.Sprintf(`	inbed.Brotli(%q, []byte("`, name)); err != nil {
+		return fmt.Errorf("could not write assets file: %w", err)
+	}
+
+	if err := writeEscaped(writer.(*bufio.Writer), bufio.NewReader(mini.Reader("encoding/br", bytes.NewReader(minified)))); err != nil {
+		return err
+	}
+
+	if _, err := writer.(*bufio.Writer).WriteString(`"))` + "\n"); err != nil {
+		return fmt.Errorf("could not write file: %w", err)
+	}
+
+	if err := writer.(*bufio.Writer).Flush(); err != nil {
+		return fmt.Errorf("could not write file: %w", err)
+	}
+
+	return nil
+}
 
+//writeEscaped writes the contents of reader as the body of a Go string literal.
+func writeEscaped(writer *bufio.Writer, reader *bufio.Reader) error {
+	for {
 		peek, err := reader.Peek(4)
 		if err != nil && len(peek) == 0 {
 			if err == io.EOF {
@@ -211,14 +253,6 @@ func embedFile(name string, w *os.File, r io.Reader) error {
 
 	}
 
-	if _, err := writer.(*bufio.Writer).WriteString(`"))` + "\n"); err != nil {
-		return fmt.Errorf("could not write file: %w", err)
-	}
-
-	if err := writer.(*bufio.Writer).Flush(); err != nil {
-		return fmt.Errorf("could not write file: %w", err)
-	}
-
 	return nil
 }
 
@@ -17,6 +17,9 @@ type file struct {
 
 	data []byte
 
+	//brotli is the brotli encoding of the file, data is gzip encoded.
+	brotli []byte
+
 	*bytes.Reader
 }
 
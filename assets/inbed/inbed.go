@@ -2,10 +2,12 @@ package inbed
 
 import (
 	"bytes"
+	"mime"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -49,30 +51,61 @@ func Data(uri string, modtime int64, mode uint32, data []byte) {
 	}
 }
 
-//ServeFile mimics http.ServeFile
-func ServeFile(w http.ResponseWriter, r *http.Request, name string) {
-	f, err := Open(name)
-	if err != nil {
-		w.WriteHeader(404)
-		return
+//Brotli is a low-level function (only available in production) for embedding the
+//brotli encoding of data previously embedded with Data.
+func Brotli(uri string, data []byte) {
+	if f, ok := files[uri]; ok {
+		f.brotli = data
+		files[uri] = f
 	}
+}
 
-	info, err := f.Stat()
-	if err != nil {
-		w.WriteHeader(500)
-		return
+//AcceptsEncoding reports whether the request accepts the given content-encoding.
+func AcceptsEncoding(r *http.Request, encoding string) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		var parts = strings.Split(accepted, ";")
+		if strings.TrimSpace(parts[0]) != encoding {
+			continue
+		}
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
 	}
+	return false
+}
 
+//serve serves the given embedded file, negotiating the content-encoding with the client.
+func serve(w http.ResponseWriter, r *http.Request, f http.File, info os.FileInfo) {
 	if path.Ext(info.Name()) == ".wasm" {
 		w.Header().Set("Content-Type", "application/wasm")
 	}
 
 	if b, ok := info.Sys().([]byte); ok {
 
-		_, production := info.(file)
-
+		embedded, production := info.(file)
 		if production {
-			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Vary", "Accept-Encoding")
+
+			if ctype := mime.TypeByExtension(path.Ext(info.Name())); ctype != "" {
+				w.Header().Set("Content-Type", ctype)
+			}
+
+			switch {
+			case embedded.brotli != nil && AcceptsEncoding(r, "br"):
+				w.Header().Set("Content-Encoding", "br")
+				b = embedded.brotli
+			case AcceptsEncoding(r, "gzip"):
+				w.Header().Set("Content-Encoding", "gzip")
+			default:
+				http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+				return
+			}
 		}
 
 		http.ServeContent(w, r, info.Name(), info.ModTime(), bytes.NewReader(b))
@@ -81,6 +114,23 @@ func ServeFile(w http.ResponseWriter, r *http.Request, name string) {
 	}
 }
 
+//ServeFile mimics http.ServeFile
+func ServeFile(w http.ResponseWriter, r *http.Request, name string) {
+	f, err := Open(name)
+	if err != nil {
+		w.WriteHeader(404)
+		return
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	serve(w, r, f, info)
+}
+
 //Open implements http.FileSystem with inbed.Open
 func (fs FileSystem) Open(name string) (http.File, error) {
 	return Open(fs.Prefix + name)
@@ -105,19 +155,5 @@ func (fs FileSystem) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if path.Ext(info.Name()) == ".wasm" {
-		w.Header().Set("Content-Type", "application/wasm")
-	}
-
-	if b, ok := info.Sys().([]byte); ok {
-
-		_, production := info.(file)
-		if production {
-			w.Header().Set("Content-Encoding", "gzip")
-		}
-
-		http.ServeContent(w, r, info.Name(), info.ModTime(), bytes.NewReader(b))
-	} else {
-		http.ServeContent(w, r, info.Name(), info.ModTime(), f)
-	}
+	serve(w, r, f, info)
 }
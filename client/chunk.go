@@ -0,0 +1,112 @@
+package client
+
+import "qlova.org/seed"
+
+//chunk is saved on seeds whose children are loaded on demand.
+type chunk struct {
+	path string
+}
+
+//Chunk splits the children of the seed into a chunk that is fetched from the given path when it is needed,
+//rather than being rendered with the document. The seed itself is still rendered, with a data-chunk attribute
+//so that the client can load its chunk, see RenderChunk.
+func Chunk(path string) seed.Option {
+	return seed.NewOption(func(c seed.Seed) {
+		c.Save(chunk{path})
+	})
+}
+
+//ChunkOf returns the path of the chunk of c, if c has been split into a chunk.
+func ChunkOf(c seed.Seed) (string, bool) {
+	var data chunk
+	c.Load(&data)
+	return data.path, data.path != ""
+}
+
+//Chunks returns the seeds within root that have been split into chunks.
+func Chunks(root seed.Seed) []seed.Seed {
+	var chunks []seed.Seed
+	if _, ok := ChunkOf(root); ok {
+		chunks = append(chunks, root)
+	}
+	for _, child := range root.Children() {
+		chunks = append(chunks, Chunks(child)...)
+	}
+	return chunks
+}
+
+//RenderChunk renders the Javascript attached to the seed of a chunk and its children.
+//Chunks within the chunk are left to be rendered on their own.
+func RenderChunk(c seed.Seed) []byte {
+	return rendered(c)
+}
+
+func init() {
+	RegisterRenderer(func(seed.Seed) []byte {
+		return []byte(`
+//seed.chunk loads the chunk of an element, its stylesheet, scripts, html and then its Javascript.
+//The chunk is only loaded once, the returned promise resolves when the chunk is ready.
+seed.chunk = function(element) {
+	if (element.chunk) return element.chunk;
+
+	element.chunk = new Promise(function(resolve, reject) {
+		let script = document.createElement("script");
+		script.src = element.dataset.chunk;
+		script.chunk = {element: element, resolve: resolve, reject: reject};
+		script.onerror = function() {
+			element.chunk = null;
+			document.head.removeChild(script);
+			reject(seed.httpErrString(0));
+		};
+		document.head.appendChild(script);
+	});
+
+	return element.chunk;
+};
+
+//seed.chunk.prefetch loads the chunk of the element with the given id, ahead of when it is needed.
+seed.chunk.prefetch = function(id) {
+	let element = q.get(id);
+	if (element && element.dataset.chunk) seed.chunk(element).catch(function() {});
+};
+
+//seed.chunk.loaded is called by the script of a chunk.
+seed.chunk.loaded = async function(script, chunk, ready) {
+	let pending = script.chunk;
+	if (!pending) return;
+
+	try {
+		let element = pending.element;
+
+		if (chunk.style && !document.querySelector('link[href="'+chunk.style+'"]')) {
+			await seed.chunk.load("link", {rel: "stylesheet", href: chunk.style});
+		}
+		for (let src of chunk.scripts) {
+			if (!document.querySelector('script[src="'+src+'"]')) await seed.chunk.load("script", {src: src});
+		}
+
+		//Prerendered elements already have their html.
+		if (!element.prerendered) element.insertAdjacentHTML("beforeend", chunk.html);
+
+		await ready();
+		pending.resolve();
+	} catch(e) {
+		pending.element.chunk = null;
+		pending.reject(e);
+	}
+};
+
+//seed.chunk.load adds an element with the given attributes to the head and waits for it to load.
+seed.chunk.load = function(tag, attributes) {
+	return new Promise(function(resolve, reject) {
+		let element = document.createElement(tag);
+		for (let key in attributes) element[key] = attributes[key];
+		element.onload = resolve;
+		element.onerror = function() { reject(seed.httpErrString(0)); };
+		document.head.appendChild(element);
+	});
+};
+`)
+	})
+}
+
@@ -129,3 +129,9 @@ func Parse(structure interface{}, arguments client.Object) (interface{}, client.
 
 	return Converted.Interface(), js.NewObject(object)
 }
+
+//ValueAs returns the value as the client type T, ie. a client.String, the same way that the
+//fields of a clientside-arguments struct are converted when it is parsed.
+func ValueAs(v js.AnyValue, T reflect.Type) reflect.Value {
+	return valueAs(v, T)
+}
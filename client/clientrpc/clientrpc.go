@@ -4,6 +4,7 @@ package clientrpc
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
@@ -60,20 +61,38 @@ type Stream interface {
 //Context for a clientrpc.Call
 type Context struct {
 	Request Request
+
+	//Data is true when the client cannot evaluate javascript (ie. under a Content Security Policy),
+	//results are then returned as JSON data.
+	Data bool
 }
 
 //Return returns a javascript function body from a Call result.
+//If ctx.Data is true, returns a JSON object with either a "result" or an "error" instead.
 func (ctx Context) Return(result interface{}, err error) {
 	w := ctx.Request.Writer()
 
+	if ctx.Data {
+		ctx.Request.SetHeader("Content-Type", "application/json")
+
+		if _, ok := result.(js.AnyScript); ok && err == nil {
+			err = errors.New("rpc function cannot return a script to a client that only accepts data")
+		}
+	}
+
 	if err != nil {
-		safe, ok := err.(clientsafe.Error)
-		if ok {
-			fmt.Fprintf(w, "throw %v;", strconv.Quote(safe.ClientError()))
+		var message = "there was an error"
+		if safe, ok := err.(clientsafe.Error); ok {
+			message = safe.ClientError()
+		}
+
+		if ctx.Data {
+			encoded, _ := json.Marshal(message)
+			fmt.Fprintf(w, `{"error":%s}`, encoded)
 			return
 		}
 
-		fmt.Fprintf(w, "throw %v;", strconv.Quote("there was an error"))
+		fmt.Fprintf(w, "throw %v;", strconv.Quote(message))
 		return
 	}
 
@@ -116,6 +135,15 @@ func (ctx Context) Return(result interface{}, err error) {
 		}
 	}
 
+	if ctx.Data {
+		if buffer.Len() == 0 {
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		fmt.Fprintf(w, `{"result":%s}`, bytes.TrimSpace(buffer.Bytes()))
+		return
+	}
+
 	//This is slow for arrays.
 	fmt.Fprintf(w, `return %v;`, buffer.String())
 }
@@ -60,3 +60,27 @@ func Hook(v client.Value, c seed.Seed) {
 
 	c.Save(data)
 }
+
+//OnChange runs the given script whenever the value changes.
+//if v is not a Variable or Compound, this is a noop
+func OnChange(v client.Value, do ...client.Script) seed.Option {
+	return seed.NewOption(func(c seed.Seed) {
+		variable, ok := v.(Variable)
+		if !ok {
+			if compound, ok := v.(client.Compound); ok {
+				for _, component := range compound.Components() {
+					OnChange(component, do...).AddTo(c)
+				}
+			}
+			return
+		}
+
+		var data data
+		c.Load(&data)
+		data.hooks = append(data.hooks, hook{
+			variable: variable,
+			do:       client.NewScript(do...),
+		})
+		c.Save(data)
+	})
+}
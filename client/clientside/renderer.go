@@ -207,6 +207,15 @@ func init() {
 		window.scope = new seed.Scope();
 		q = window.scope;
 
+		//state is the initial state that the server injected into the document for this client.
+		seed.state = function() {
+			if (!seed.state.data) {
+				let element = document.getElementById("seed.state");
+				seed.state.data = element ? JSON.parse(element.textContent) : {};
+			}
+			return seed.state.data;
+		};
+
 		`)
 
 		//Deterministic render.
@@ -242,6 +251,9 @@ func init() {
 			}
 		}
 
+		//Initial state overrides the default values.
+		b.WriteString(`for (let [address, memory, value] of (seed.state().vars || [])) q.setvar(address, memory, value);`)
+
 		return b.Bytes()
 	})
 }
@@ -0,0 +1,8 @@
+package clientside
+
+//PendingSync is true when the client has queued calls that are waiting to be sent to the server, see client.Queue
+var PendingSync = &Bool{
+	MemoryAddress: MemoryAddress{
+		Name: "seed.queue.pending",
+	},
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"math/big"
 	"reflect"
+	"runtime"
 
 	"qlova.org/seed/use/js"
 	"qlova.org/seed/use/wasm"
@@ -11,6 +12,16 @@ import (
 
 var goExports = make(map[string]reflect.Value)
 
+//FunctionName returns the name of the Go function that is exported to the client with the given id,
+//this is the id at the end of a /go/ path.
+func FunctionName(id string) (string, bool) {
+	f, ok := goExports[id]
+	if !ok {
+		return "", false
+	}
+	return runtime.FuncForPC(f.Pointer()).Name(), true
+}
+
 //MutableFloat is a float that can be mutated.
 type MutableFloat interface {
 	Float
@@ -101,6 +112,8 @@ func rpc(q js.Ctx, fn interface{}, args ...Value) (progress recording, CallingSt
 
 //Go requests the client to call the given Go function in a new goroutine, with the given client Values automatically converted to equivalent Go values and are passed to the given function.
 //The function can optionally take a Ctx as the first argument, if so, then it is passed to the function and arguments are assigned to the following arguments.
+//Functions that return a Script cannot be called by apps with a Content Security Policy (see app.Security), as the
+//client cannot evaluate the script, the call fails with an error instead.
 func Go(fn interface{}, args ...Value) Script {
 	return js.Script(func(q js.Ctx) {
 		rec, CallingString, formdata := rpc(q, fn, args...)
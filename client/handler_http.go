@@ -23,22 +23,31 @@ func Handler(w http.ResponseWriter, r *http.Request, id string) {
 		return
 	}
 
+	//Queued calls may be replayed more than once, only the first is handled.
+	var key = r.Header.Get("Idempotency-Key")
+	if key != "" && !claim(key) {
+		return
+	}
+
 	var cr = NewRequest(w, r)
 
-	ctx := clientrpc.Context{Request: cr}
+	ctx := clientrpc.Context{
+		Request: cr,
+		Data:    strings.Contains(r.Header.Get("Accept"), "application/json"),
+	}
 
 	var args []interface{}
 
 	for i := 0; i < f.Type().NumIn(); i++ {
-		var key = string(rune('a' + i))
+		var name = string(rune('a' + i))
 
-		file, header, err := cr.request.FormFile(key)
+		file, header, err := cr.request.FormFile(name)
 		if err == nil {
 			args = append(args, Stream{header, file})
 			continue
 		}
 
-		s := cr.request.FormValue(key)
+		s := cr.request.FormValue(name)
 		if strings.HasPrefix(s, "\"") {
 			s, err = strconv.Unquote(s)
 			if err != nil {
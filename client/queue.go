@@ -0,0 +1,194 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"qlova.org/seed"
+	"qlova.org/seed/use/js"
+)
+
+//Queue is like Go, except that if the client is offline, the call and its arguments are persisted
+//on the client and replayed once the client reconnects, either by the service worker's background
+//sync or when the app is next online. Each queued call has an idempotency key, so that replays
+//are only applied once, see Request.IdempotencyKey. Calls that return an error are not retried,
+//the error is returned to the client like Go.
+func Queue(fn interface{}, args ...Value) Script {
+	return js.Script(func(q js.Ctx) {
+		_, CallingString, formdata := rpc(q, fn, args...)
+
+		q([]byte(`await seed.queue(` + formdata + `, "` + CallingString + `");`))
+	})
+}
+
+//OnSynced is called whenever a queued call has been completed, with the idempotency key of the call.
+func OnSynced(do func(key String) Script) seed.Option {
+	return On("synced", NewScript(
+		do(js.String{Value: js.NewValue(`arguments[0]`)}),
+	))
+}
+
+//idempotencyWindow is how long the idempotency keys of completed calls are remembered.
+const idempotencyWindow = 24 * time.Hour
+
+//idempotency keys of calls that have been handled, or are being handled.
+var idempotency = struct {
+	sync.Mutex
+	keys map[string]time.Time
+}{keys: make(map[string]time.Time)}
+
+//claim returns true if the call with the given idempotency key should be handled.
+func claim(key string) bool {
+	idempotency.Lock()
+	defer idempotency.Unlock()
+
+	var now = time.Now()
+	for existing, at := range idempotency.keys {
+		if now.Sub(at) > idempotencyWindow {
+			delete(idempotency.keys, existing)
+		}
+	}
+
+	if _, ok := idempotency.keys[key]; ok {
+		return false
+	}
+
+	idempotency.keys[key] = now
+	return true
+}
+
+func init() {
+	RegisterRenderer(func(c seed.Seed) []byte {
+		return []byte(`
+seed.queue = async function(formdata, url) {
+	let item = {
+		key: (window.crypto && crypto.randomUUID) ? crypto.randomUUID() : Date.now().toString(36) + Math.random().toString(36).slice(2),
+		url: url,
+		headers: {},
+		entries: Array.from(formdata.entries()),
+		time: Date.now(),
+	};
+	item.headers["Idempotency-Key"] = item.key;
+	if (seed.csp) item.headers["Accept"] = "application/json";
+
+	if (navigator.onLine) {
+		let response;
+		try {
+			response = await seed.queue.send(item);
+		} catch(e) {
+			//Only network failures are queued.
+			response = null;
+		}
+		if (response != null) {
+			await seed.queue.complete(item.key, response);
+			return;
+		}
+	}
+
+	await seed.queue.transaction("readwrite", store => store.put(item));
+	await seed.queue.update();
+
+	try {
+		let registration = await navigator.serviceWorker.ready;
+		if (registration.sync) {
+			await registration.sync.register("seed.queue");
+		}
+	} catch(e) {
+		//Background sync is unavailable, the queue is flushed when the app is online.
+	}
+};
+
+seed.queue.db = function() {
+	return new Promise(function(resolve, reject) {
+		let request = indexedDB.open("seed", 1);
+		request.onupgradeneeded = function() {
+			request.result.createObjectStore("queue", {keyPath: "key"});
+		};
+		request.onsuccess = function() { resolve(request.result); };
+		request.onerror = function() { reject(request.error); };
+	});
+};
+
+seed.queue.transaction = async function(mode, f) {
+	let db = await seed.queue.db();
+	return new Promise(function(resolve, reject) {
+		let tx = db.transaction("queue", mode);
+		let request = f(tx.objectStore("queue"));
+		tx.oncomplete = function() { resolve(request.result); };
+		tx.onerror = function() { reject(tx.error); };
+	});
+};
+
+//send sends a queued item, returning the response body and type or throwing on network failure.
+seed.queue.send = async function(item) {
+	let body = new FormData();
+	for (let [key, value] of item.entries) body.append(key, value);
+
+	let response = await fetch(item.url, {
+		method: "POST",
+		body: body,
+		headers: item.headers,
+	});
+	if (response.status >= 500) throw seed.httpErrString(response.status);
+
+	return {body: await response.text(), type: response.headers.get("Content-Type")};
+};
+
+//complete runs the response of a completed item and fires the synced event.
+seed.queue.complete = async function(key, response) {
+	await seed.response(response.body, response.type);
+
+	for (let element of document.querySelectorAll("*")) {
+		if (element.onsynced) await element.onsynced(key);
+	}
+};
+
+seed.queue.update = async function() {
+	let count = await seed.queue.transaction("readonly", store => store.count());
+	await q.setvar("seed.queue.pending", "", count > 0);
+};
+
+seed.queue.flush = async function() {
+	if (seed.queue.flushing) return;
+	seed.queue.flushing = true;
+
+	try {
+		let items = await seed.queue.transaction("readonly", store => store.getAll());
+		for (let item of items) {
+			let response = await seed.queue.send(item);
+			await seed.queue.transaction("readwrite", store => store.delete(item.key));
+			try {
+				await seed.queue.complete(item.key, response);
+			} catch(e) {
+				seed.report(e);
+			}
+		}
+	} catch(e) {
+		//Still offline, try again later.
+	} finally {
+		seed.queue.flushing = false;
+		await seed.queue.update();
+	}
+};
+
+if (window.indexedDB) {
+	window.addEventListener("online", seed.queue.flush);
+
+	if ('serviceWorker' in navigator) {
+		navigator.serviceWorker.addEventListener("message", async function(event) {
+			if (!event.data || event.data.type != "seed.queue") return;
+
+			try {
+				await seed.queue.complete(event.data.key, event.data.response);
+			} catch(e) {
+				seed.report(e);
+			}
+			await seed.queue.update();
+		});
+	}
+
+	setTimeout(seed.queue.flush);
+}
+`)
+	})
+}
@@ -29,6 +29,15 @@ func init() {
 }
 
 func render(child seed.Seed) []byte {
+	//Chunks are rendered on their own, see RenderChunk.
+	if _, ok := ChunkOf(child); ok {
+		return nil
+	}
+	return rendered(child)
+}
+
+//rendered renders the Javascript of a seed and its children, even if the seed is a chunk.
+func rendered(child seed.Seed) []byte {
 	var b bytes.Buffer
 	var d Data
 	child.Load(&d)
@@ -276,12 +285,32 @@ seed.download = async function(name, path) {
 
 window.AsyncFunction = Object.getPrototypeOf(async function(){}).constructor;
 
+//csp is true when the app has a Content Security Policy, responses are then requested as data.
+seed.csp = false;
+
+//response runs the response of a call, data responses are decoded instead of being evaluated.
+seed.response = async function(response, type) {
+	if (response == "") return null;
+
+	if (type && type.indexOf("application/json") == 0) {
+		let data = JSON.parse(response);
+		if (data.update) {
+			if (seed.production && document.body.onupdatefound) await document.body.onupdatefound();
+			throw "";
+		}
+		if ("error" in data) throw data.error;
+		return data.result;
+	}
+
+	return await (new AsyncFunction(response))();
+}
+
 seed.request = async function(method, formdata, url, manual, active, onprogress) {
 
-	const slave = async function(response) {
-		if (response == "") return null;
+	let type = null;
 
-		return await (new AsyncFunction(response))();
+	const slave = async function(response) {
+		return await seed.response(response, type);
 	}
 
 	if (window.rpc && rpc[url]) {
@@ -311,6 +340,7 @@ seed.request = async function(method, formdata, url, manual, active, onprogress)
 		}
 
 		xhr.onload = function () {
+			type = xhr.getResponseHeader("Content-Type");
 			if (this.status >= 200 && this.status < 300) {
 				resolve(xhr.response);
 			} else {
@@ -331,6 +361,7 @@ seed.request = async function(method, formdata, url, manual, active, onprogress)
 		};
 
 		xhr.open(method, url, true);
+		if (seed.csp) xhr.setRequestHeader("Accept", "application/json");
 		xhr.send(formdata);
 	});
 
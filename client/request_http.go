@@ -66,6 +66,12 @@ func NewRequest(w http.ResponseWriter, r *http.Request) Request {
 	}
 }
 
+//IdempotencyKey returns the idempotency key of a queued call, or the empty string if
+//the request is not a queued call. Replays of a queued call share the same key.
+func (cr Request) IdempotencyKey() string {
+	return cr.request.Header.Get("Idempotency-Key")
+}
+
 //Arg returns the named query value with the given name.
 func (cr Request) Arg(name string) string {
 	return cr.request.FormValue(name)
@@ -138,6 +144,15 @@ type Stream struct {
 	file multipart.File
 }
 
+//NewStream returns a Stream that reads the given multipart file.
+func NewStream(header *multipart.FileHeader) (Stream, error) {
+	file, err := header.Open()
+	if err != nil {
+		return Stream{}, err
+	}
+	return Stream{header, file}, nil
+}
+
 //Name returns the name of the steam, if a file is being sent, this is the name of the file.
 func (s Stream) Name() string {
 	if s.head == nil {
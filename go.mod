@@ -3,7 +3,7 @@ module qlova.org/seed
 go 1.13
 
 require (
-	github.com/NYTimes/gziphandler v1.1.1
+	github.com/andybalholm/brotli v1.0.3
 	github.com/chris-ramon/douceur v0.2.0 // indirect
 	github.com/gomarkdown/markdown v0.0.0-20200824053859-8c8b3816f167
 	github.com/gorilla/websocket v1.4.2
@@ -13,6 +13,7 @@ require (
 	github.com/tdewolff/minify v2.3.6+incompatible
 	github.com/tdewolff/minify/v2 v2.7.3
 	github.com/tdewolff/parse v2.3.4+incompatible // indirect
+	golang.org/x/net v0.0.0-20210614182718-04defd469f4e
 	qlova.org/mirror v0.1.3-0.20210211122219-b3c1ea959516
 	qlova.org/should v1.0.2
 	qlova.tech v0.0.0-20210605093230-284eec3f1021
@@ -2,8 +2,10 @@ package app
 
 import (
 	"image/color"
+	"io"
 
 	"qlova.org/seed"
+	"qlova.org/seed/client"
 	"qlova.org/seed/client/clientside"
 	"qlova.org/seed/new/app/manifest"
 	"qlova.org/seed/new/app/service"
@@ -30,12 +32,42 @@ type app struct {
 
 	name, description, pkg string
 
+	//url is the public url of the app.
+	url string
+
 	hashes []string
 
-	page, loadingPage page.Page
+	//etags of fingerprinted resources.
+	etags map[string]string
+
+	//chunks of the lazy pages, by their path.
+	chunks map[string][]byte
+
+	page, loadingPage, offlinePage, notFoundPage page.Page
+
+	//aliases are the custom routes of the app.
+	aliases []alias
 
 	color color.Color
 
+	//icon is the source image of the generated icons.
+	icon  string
+	icons map[string][]byte
+
+	security Security
+
+	//accessLog and metrics are opt-in.
+	accessLog io.Writer
+	metrics   *metrics
+
+	probes *probes
+
+	//state is called for each request of the document.
+	state func(client.Request, *State) error
+
+	//shares are routed from the share target and file handlers.
+	shares []share
+
 	head []seed.Option
 }
 
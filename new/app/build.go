@@ -2,8 +2,10 @@ package app
 
 import (
 	"fmt"
+	"log"
 
 	"qlova.org/seed"
+	"qlova.org/seed/assets"
 	"qlova.org/seed/client"
 	"qlova.org/seed/client/clientside"
 	"qlova.org/seed/new/app/manifest"
@@ -62,15 +64,84 @@ func (a App) build() {
 		popup.Harvest(),
 	)
 
+	if app.offlinePage != nil {
+		app.document.Body.With(page.AddPages(app.offlinePage))
+		app.worker.Offline = pathOf(app.document.Body, app.offlinePage, "/offline")
+	}
+
+	if app.notFoundPage == nil {
+		app.notFoundPage = notFound{}
+	}
+	if !harvested(app.document.Body, app.notFoundPage) {
+		app.document.Body.With(page.AddPages(app.notFoundPage))
+	}
+	for _, alias := range app.aliases {
+		if !harvested(app.document.Body, alias.page) {
+			app.document.Body.With(page.AddPages(alias.page))
+		}
+	}
+
+	//Shares land on the page at the path they were posted to, unless the page has its own path.
+	var launch string
+	for i, share := range app.shares {
+		var path = pathOf(app.document.Body, share.landing, share.action)
+		if path == "" {
+			app.document.Body.With(page.AddPages(share.landing))
+			path = pathOf(app.document.Body, share.landing, share.action)
+		}
+		app.shares[i].path = path
+	}
+	if len(app.manifest.FileHandlers) > 0 {
+		launch = launchJS
+	}
+
 	for _, template := range feed.Templates(app.document.Body) {
 		app.document.Body.With(template)
 	}
 
-	var onready = string(client.Render(a.Seed))
 	var scripts = js.Scripts(a.Seed)
 	var stylesheets = css.Stylesheets(a.Seed)
 
-	app.worker.Assets = asset.Of(a.Seed)
+	//Scripts that are only required by lazy pages are loaded with their chunks.
+	var linked = make(map[string]string, len(scripts))
+	required(a.Seed, linked)
+
+	var embedded = asset.Of(a.Seed)
+	references(a.Seed, embedded)
+
+	var local = make(map[string]string, len(embedded))
+	for src := range embedded {
+		local[src] = ""
+	}
+
+	app.etags = make(map[string]string)
+	fingerprint(app.etags, scripts, stylesheets, js.Imports(), local)
+
+	if app.icon != "" {
+		background, _ := manifest.ParseColor(app.manifest.BackgroundColor)
+
+		icons, files, err := manifest.GenerateIcons(app.icon, background)
+		if err != nil {
+			log.Println(err)
+			app.manifest.SetIcon(app.icon)
+		} else {
+			app.manifest.Icons = icons
+			app.icons = files
+		}
+	}
+
+	var onready = string(client.Render(a.Seed))
+
+	app.chunks = chunks(a.Seed, linked, app.etags)
+
+	app.worker.Assets = make(map[string]bool, len(embedded)+len(app.chunks))
+	for src := range embedded {
+		app.worker.Assets[assets.Fingerprinted(src)] = true
+	}
+	//Chunks are precached, so that lazy pages are available offline.
+	for path := range app.chunks {
+		app.worker.Assets[assets.Fingerprinted(path)] = true
+	}
 	a.Seed.Save(app)
 
 	app.document.Head.With(
@@ -103,8 +174,36 @@ func (a App) build() {
 
 		link.Manifest("/app.webmanifest"),
 
+		//Link the generated icons.
+		seed.If(app.icons != nil,
+			link.New(
+				attr.Set("rel", "icon"),
+				attr.Set("href", manifest.Favicon),
+				attr.Set("sizes", "16x16 32x32 48x48"),
+			),
+			link.New(
+				attr.Set("rel", "apple-touch-icon"),
+				attr.Set("sizes", "180x180"),
+				attr.Set("href", manifest.AppleTouchIcon),
+			),
+			repeater.New(app.manifest.Icons, repeater.Do(func(c repeater.Seed) {
+				var icon = c.Data.Interface().(manifest.Icon)
+
+				if icon.Purpose == "maskable" {
+					return
+				}
+
+				c.With(link.New(
+					attr.Set("rel", "icon"),
+					attr.Set("type", icon.Type),
+					attr.Set("sizes", icon.Sizes),
+					attr.Set("href", icon.Source),
+				))
+			})),
+		),
+
 		//Add icons to app.
-		repeater.New(app.manifest.Icons, repeater.Do(func(c repeater.Seed) {
+		seed.If(app.icons == nil, repeater.New(app.manifest.Icons, repeater.Do(func(c repeater.Seed) {
 			var icon = c.Data.Interface().(manifest.Icon)
 
 			//The first icon can be the Favicon. TODO better heuristic? allow other file types.
@@ -121,21 +220,21 @@ func (a App) build() {
 				attr.Set("sizes", icon.Sizes),
 				attr.Set("href", icon.Source),
 			))
-		})),
+		}))),
 
 		style.New(html.Set(builtinCSS+normaliseCSS+string(css.Render(a.Seed)))),
 
 		//Add external scripts.
-		repeater.New(scripts, repeater.Do(func(c repeater.Seed) {
+		repeater.New(linked, repeater.Do(func(c repeater.Seed) {
 			c.With(script_html.New(
-				attr.Set("src", c.Data.Index().String()),
+				attr.Set("src", assets.Fingerprinted(c.Data.Index().String())),
 				attr.Set("defer", ""),
 			))
 		})),
 
 		repeater.New(stylesheets, repeater.Do(func(c repeater.Seed) {
 			c.With(link.New(
-				attr.Set("href", c.Data.Index().String()),
+				attr.Set("href", assets.Fingerprinted(c.Data.Index().String())),
 				attr.Set("rel", "stylesheet"),
 			))
 		})),
@@ -303,7 +402,48 @@ func (a App) build() {
 
 				if (seed.goto) await seed.goto.ready();
 
+				`+launch+`
 				});`),
 		),
 	)
 }
+
+//launchJS posts files that the app was launched with, to the file handler they were opened with.
+const launchJS = `if ('launchQueue' in window) launchQueue.setConsumer(async function(params) {
+	if (!params.files || !params.files.length) return;
+
+	let form = new FormData();
+	for (let handle of params.files) form.append("files", await handle.getFile());
+
+	let response = await fetch(new URL(params.targetURL).pathname, {method: "POST", body: form});
+	if (!response.ok) throw await response.text();
+
+	history.replaceState(null, "", response.url);
+	await seed.goto.ready();
+});`
+
+//pathOf returns the url path of the harvested page, the page is given
+//the fallback path if it does not already have one.
+//Returns the empty string if the page has not been harvested.
+func pathOf(c seed.Seed, p page.Page, fallback string) string {
+	var data html.Data
+	c.Load(&data)
+
+	for _, class := range data.Classes {
+		if "."+class == page.ID(p) {
+			if path, ok := data.Attributes["data-path"]; ok && path != "" {
+				return path
+			}
+			c.With(page.SetPath(fallback))
+			return fallback
+		}
+	}
+
+	for _, child := range c.Children() {
+		if path := pathOf(child, p, fallback); path != "" {
+			return path
+		}
+	}
+
+	return ""
+}
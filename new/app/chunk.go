@@ -0,0 +1,91 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"qlova.org/seed"
+	"qlova.org/seed/assets"
+	"qlova.org/seed/client"
+	"qlova.org/seed/use/css"
+	"qlova.org/seed/use/html"
+	"qlova.org/seed/use/js"
+)
+
+//required adds the scripts required by c and its children into fill, leaving out the scripts of any chunks within c.
+func required(c seed.Seed, fill map[string]string) {
+	for path, contents := range js.Required(c) {
+		fill[path] = contents
+	}
+
+	for _, child := range c.Children() {
+		if _, ok := client.ChunkOf(child); !ok {
+			required(child, fill)
+		}
+	}
+}
+
+//chunks renders the lazily loaded parts of the app, see client.Chunk.
+//Each chunk is a script that carries the html of the chunk, along with a stylesheet, by their path.
+//Scripts that are only required within a chunk are loaded with the chunk, rather than linked by the document.
+//The chunks are fingerprinted and their ETags are stored in etags.
+func chunks(root seed.Seed, linked map[string]string, etags map[string]string) map[string][]byte {
+	var rendered = make(map[string][]byte)
+
+	var add = func(path string, content []byte) {
+		assets.Fingerprint(path, content)
+		etags[path] = strconv.Quote(assets.Hash(content))
+		rendered[path] = content
+	}
+
+	for _, c := range client.Chunks(root) {
+		var path, _ = client.ChunkOf(c)
+
+		var chunk struct {
+			HTML    string   `json:"html"`
+			Style   string   `json:"style,omitempty"`
+			Scripts []string `json:"scripts"`
+		}
+
+		var ready = client.RenderChunk(c)
+
+		if style := css.RenderChunk(c); len(style) > 0 {
+			var stylesheet = strings.TrimSuffix(path, ".js") + ".css"
+			add(stylesheet, style)
+			chunk.Style = assets.Fingerprinted(stylesheet)
+		}
+
+		var scripts = make(map[string]string)
+		required(c, scripts)
+
+		chunk.Scripts = make([]string, 0, len(scripts))
+		for src := range scripts {
+			if _, ok := linked[src]; !ok {
+				chunk.Scripts = append(chunk.Scripts, assets.Fingerprinted(src))
+			}
+		}
+		sort.Strings(chunk.Scripts)
+
+		var b bytes.Buffer
+		for _, child := range c.Children() {
+			b.Write(html.Render(child))
+		}
+		chunk.HTML = string(fingerprinted(b.Bytes()))
+
+		encoded, _ := json.Marshal(chunk)
+
+		var script bytes.Buffer
+		script.WriteString("seed.chunk.loaded(document.currentScript, ")
+		script.Write(encoded)
+		script.WriteString(", async function() {\n")
+		script.Write(ready)
+		script.WriteString("\n});\n")
+
+		add(path, script.Bytes())
+	}
+
+	return rendered
+}
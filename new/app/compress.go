@@ -0,0 +1,100 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+
+	"github.com/andybalholm/brotli"
+
+	"qlova.org/seed/assets/inbed"
+)
+
+//static is a static response that has been compressed ahead of time.
+type static struct {
+	contentType string
+
+	//Cache-Control and ETag headers.
+	control, etag string
+
+	identity, gzip, brotli []byte
+}
+
+//precompress compresses the given data with every supported content-encoding.
+//Small responses are left uncompressed, as the compression overhead would outweigh the savings.
+func precompress(contentType string, data []byte) static {
+	var s = static{
+		contentType: contentType,
+		identity:    data,
+	}
+
+	if len(data) < 256 {
+		return s
+	}
+
+	var buffer bytes.Buffer
+
+	gw, _ := gzip.NewWriterLevel(&buffer, gzip.BestCompression)
+	gw.Write(data)
+	gw.Close()
+
+	if buffer.Len() < len(data) {
+		s.gzip = append([]byte(nil), buffer.Bytes()...)
+	}
+
+	buffer.Reset()
+
+	bw := brotli.NewWriterLevel(&buffer, brotli.BestCompression)
+	bw.Write(data)
+	bw.Close()
+
+	if buffer.Len() < len(data) {
+		s.brotli = append([]byte(nil), buffer.Bytes()...)
+	}
+
+	return s
+}
+
+//cache returns a copy of the static response that is served with the given caching headers.
+func (s static) cache(control, etag string) static {
+	s.control = control
+	s.etag = etag
+	return s
+}
+
+//ServeHTTP implements http.Handler, serving the best encoding accepted by the client.
+func (s static) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var header = w.Header()
+
+	if s.contentType != "" {
+		header.Set("Content-Type", s.contentType)
+	}
+
+	if s.gzip != nil || s.brotli != nil {
+		header.Set("Vary", "Accept-Encoding")
+	}
+
+	if s.control != "" && cached(w, r, s.etag, s.control) {
+		return
+	}
+
+	var body = s.identity
+
+	switch {
+	case s.brotli != nil && inbed.AcceptsEncoding(r, "br"):
+		header.Set("Content-Encoding", "br")
+		body = s.brotli
+	case s.gzip != nil && inbed.AcceptsEncoding(r, "gzip"):
+		header.Set("Content-Encoding", "gzip")
+		body = s.gzip
+	}
+
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	w.Write(body)
+}
@@ -7,7 +7,9 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"qlova.org/seed/assets"
 	"qlova.org/seed/assets/inbed"
 	"qlova.org/seed/use/js"
 )
@@ -29,38 +31,40 @@ func (a App) Export() error {
 
 	a.build()
 
-	var rendered = app.document.Render()
+	a.Load(&app)
 
-	var document, err = mini(rendered)
-	if err != nil {
-		document = rendered
-	}
+	var document = app.minified()
 
 	//Todo package other assets.
 	var scripts = js.Scripts(app.document.Seed)
 
 	for path, script := range scripts {
-		opath := path
-		path = "export/" + path
+		b, ok := content(path, script)
+		if !ok {
+			continue
+		}
 
-		dir := filepath.Dir(path)
-		os.MkdirAll(dir, os.ModePerm)
+		//Export both the original and the content-hashed path.
+		for _, path := range []string{path, assets.Fingerprinted(path)} {
+			path = "export/" + path
 
-		if script == "" {
-			f, err := inbed.Open(opath)
-			if err == nil {
-				b, err := ioutil.ReadAll(f)
-				if err != nil {
-					return err
-				}
-
-				if err := ioutil.WriteFile(path, b, os.ModePerm); err != nil {
-					return err
-				}
+			dir := filepath.Dir(path)
+			os.MkdirAll(dir, os.ModePerm)
+
+			if err := ioutil.WriteFile(path, b, os.ModePerm); err != nil {
+				return err
 			}
-		} else {
+		}
+	}
 
-			if err := ioutil.WriteFile(path, []byte(script), os.ModePerm); err != nil {
+	//Chunks of lazy pages.
+	for path, chunk := range app.chunks {
+		for _, path := range []string{path, assets.Fingerprinted(path)} {
+			path = "export/" + path
+
+			os.MkdirAll(filepath.Dir(path), os.ModePerm)
+
+			if err := ioutil.WriteFile(path, chunk, os.ModePerm); err != nil {
 				return err
 			}
 		}
@@ -103,6 +107,13 @@ func (a App) Export() error {
 			return err
 		}
 	}
+	for path, data := range app.icons {
+		var file = filepath.Join("export", filepath.FromSlash(path))
+		os.MkdirAll(filepath.Dir(file), os.ModePerm)
+		if err := ioutil.WriteFile(file, data, os.ModePerm); err != nil {
+			return err
+		}
+	}
 	{
 		var manifest = app.manifest.Render()
 		if err := ioutil.WriteFile("export/app.webmanifest", manifest, os.ModePerm); err != nil {
@@ -110,7 +121,14 @@ func (a App) Export() error {
 		}
 	}
 	{
-		if err := ioutil.WriteFile("export/robots.txt", []byte("\n"), os.ModePerm); err != nil {
+		if err := ioutil.WriteFile("export/robots.txt", app.robots(app.url), os.ModePerm); err != nil {
+			return err
+		}
+	}
+	//Prerendered pages are derived from the unminified document.
+	var pages, notFound = app.prerender(app.document.Render())
+	if app.url != "" {
+		if err := ioutil.WriteFile("export/sitemap.xml", app.sitemap(pages, app.url), os.ModePerm); err != nil {
 			return err
 		}
 	}
@@ -124,6 +142,35 @@ func (a App) Export() error {
 			return err
 		}
 	}
+	if app.worker.Offline != "" {
+		//The offline page is precached by the service worker, so it needs to exist.
+		var dir = filepath.Join("export", filepath.FromSlash(app.worker.Offline))
+		os.MkdirAll(dir, os.ModePerm)
+		if err := ioutil.WriteFile(filepath.Join(dir, "index.html"), document, os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	//Prerendered pages are exported to the index.html of their path. Patterns can't be exported as
+	//files and the root is the shell document.
+	for _, p := range pages {
+		if strings.ContainsAny(p.Path, "{*") || strings.Trim(p.Path, "/") == "" {
+			continue
+		}
+
+		var dir = filepath.Join("export", filepath.FromSlash(p.Path))
+		os.MkdirAll(dir, os.ModePerm)
+		if err := ioutil.WriteFile(filepath.Join(dir, "index.html"), p.document, os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	//Static hosts serve 404.html for paths that don't exist.
+	if route, ok := notFound.initial("", "", true); ok {
+		if err := ioutil.WriteFile("export/404.html", embed(notFound.document, "seed.route", route), os.ModePerm); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
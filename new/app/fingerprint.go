@@ -0,0 +1,140 @@
+package app
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"qlova.org/seed"
+	"qlova.org/seed/assets"
+	"qlova.org/seed/assets/inbed"
+	"qlova.org/seed/use/html"
+
+	xhtml "golang.org/x/net/html"
+)
+
+//immutable is the Cache-Control header used for content-hashed resources.
+const immutable = "public, max-age=31536000, immutable"
+
+//revalidate is the Cache-Control header used for resources that must be checked on every request.
+const revalidate = "no-cache"
+
+//content returns the content of a script, stylesheet or asset at the given path.
+//If contents is empty, then the path is opened with inbed.
+func content(path string, contents string) ([]byte, bool) {
+	if contents != "" {
+		return []byte(contents), true
+	}
+
+	if strings.HasPrefix(path, "http") || strings.Contains(path, "?") {
+		return nil, false
+	}
+
+	f, err := inbed.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, false
+	}
+
+	return b, true
+}
+
+//references adds any local asset paths referenced by the HTML attributes of c and its children.
+func references(c seed.Seed, into map[string]bool) {
+	var data html.Data
+	c.Load(&data)
+
+	for _, value := range data.Attributes {
+		if strings.HasPrefix(value, "/assets/") {
+			into[value] = true
+		}
+	}
+
+	for _, child := range c.Children() {
+		references(child, into)
+	}
+}
+
+//fingerprint content-hashes the given scripts, stylesheets, imports and assets
+//so that they can be served with long-lived immutable caching.
+//The ETag of each fingerprinted path is stored in etags.
+func fingerprint(etags map[string]string, resources ...map[string]string) {
+	for _, resource := range resources {
+		for path, contents := range resource {
+			if b, ok := content(path, contents); ok {
+				assets.Fingerprint(path, b)
+				etags[path] = strconv.Quote(assets.Hash(b))
+			}
+		}
+	}
+}
+
+//fingerprinted rewrites the references to fingerprinted assets inside of the given document.
+func fingerprinted(document []byte) []byte {
+	var b bytes.Buffer
+	if err := fingerprintTo(&b, bytes.NewReader(document)); err != nil {
+		return document
+	}
+	return b.Bytes()
+}
+
+//fingerprintTo copies the HTML document from r to w, rewriting the src and href attributes that refer to
+//fingerprinted assets. Everything else, including text and scripts, is copied as is.
+func fingerprintTo(w io.Writer, r io.Reader) error {
+	var sources = assets.Fingerprints()
+
+	var tokenizer = xhtml.NewTokenizer(r)
+	for {
+		var token = tokenizer.Next()
+		if token == xhtml.ErrorToken {
+			if err := tokenizer.Err(); err != io.EOF {
+				return err
+			}
+			return nil
+		}
+
+		var raw = tokenizer.Raw()
+		if (token == xhtml.StartTagToken || token == xhtml.SelfClosingTagToken) &&
+			(bytes.Contains(raw, []byte(" src=")) || bytes.Contains(raw, []byte(" href="))) {
+			for _, src := range sources {
+				var quoted, replacement = strconv.Quote(src), strconv.Quote(assets.Fingerprinted(src))
+				for _, attr := range []string{" src=", " href="} {
+					raw = bytes.Replace(raw, []byte(attr+quoted), []byte(attr+replacement), -1)
+				}
+			}
+		}
+
+		if _, err := w.Write(raw); err != nil {
+			return err
+		}
+	}
+}
+
+//cached handles conditional requests for content with the given ETag,
+//returns true if the client already has the content and a 304 was written.
+func cached(w http.ResponseWriter, r *http.Request, etag string, control string) bool {
+	w.Header().Set("Cache-Control", control)
+	if etag == "" {
+		return false
+	}
+
+	w.Header().Set("ETag", etag)
+
+	for _, match := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		match = strings.TrimPrefix(strings.TrimSpace(match), "W/")
+		if match == etag || match == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
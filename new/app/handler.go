@@ -1,20 +1,23 @@
 package app
 
 import (
+	"bytes"
 	"crypto/md5"
 	"encoding/hex"
-	"fmt"
 	"log"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/NYTimes/gziphandler"
-
+	"qlova.org/seed/assets"
 	"qlova.org/seed/assets/inbed"
 	"qlova.org/seed/client"
 	"qlova.org/seed/new/api"
+	"qlova.org/seed/new/app/manifest"
+	"qlova.org/seed/new/feed"
+	"qlova.org/seed/new/page"
 	"qlova.org/seed/use/css"
 	"qlova.org/seed/use/js"
 )
@@ -67,12 +70,9 @@ func (a App) Handler() http.Handler {
 
 	a.build()
 
-	var rendered = app.document.Render()
+	a.Load(&app)
 
-	var document, err = mini(rendered)
-	if err != nil {
-		document = rendered
-	}
+	var document = app.minified()
 
 	var scripts = js.Scripts(app.document.Seed)
 	var stylesheets = css.Stylesheets(app.document.Seed)
@@ -85,23 +85,65 @@ func (a App) Handler() http.Handler {
 	var version = hex.EncodeToString(checksum[:])
 
 	app.worker.Version = version
+	app.worker.Fresh = app.state != nil
 
-	var worker = app.worker.Render()
+	var worker = precompress("text/javascript", app.worker.Render())
 
-	router.Handle("/Qlovaseed.png", gziphandler.GzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "image/png")
-		icon, _ := fsByte(false, "/Qlovaseed.png")
-		w.Write(icon)
-		return
-	})))
+	//Don't use a web worker if we are running locally.
+	var localWorker = precompress("text/javascript", []byte(`self.addEventListener('install', () => {self.skipWaiting();});`))
+
+	//Precompress every static response once, instead of on every request.
+	var statics = make(map[string]static, len(scripts)+len(stylesheets)+len(imports))
+	for path, content := range imports {
+		statics[path] = precompress("text/javascript", []byte(content))
+	}
+	for path, content := range stylesheets {
+		statics[path] = precompress("text/css", []byte(content))
+	}
+	for path, content := range scripts {
+		statics[path] = precompress("text/javascript", []byte(content))
+	}
+	for path, content := range app.chunks {
+		var contentType = "text/javascript"
+		if strings.HasSuffix(path, ".css") {
+			contentType = "text/css"
+		}
+		statics[path] = precompress(contentType, content)
+	}
+
+	var index = precompress("text/html; charset=utf-8", document).cache(revalidate, strconv.Quote(version))
+
+	//Pages with a path are served with their own document, so that crawlers and link previews can see them.
+	//Prerendered pages are derived from the unminified document.
+	var pages, notFound = app.prerender(app.document.Render())
+
+	icon, _ := fsByte(false, "/Qlovaseed.png")
+	router.Handle("/Qlovaseed.png", precompress("image/png", icon))
+
+	//Generated icons.
+	for path, data := range app.icons {
+		var contentType = "image/png"
+		if path == manifest.Favicon {
+			contentType = "image/x-icon"
+		}
+		router.Handle(path, precompress(contentType, data).cache(revalidate, strconv.Quote(assets.Hash(data))))
+	}
 
 	router.Handle("/assets/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if original, ok := assets.Original(r.URL.Path); ok {
+			r.URL.Path = original
+			w.Header().Set("Cache-Control", immutable)
+			w.Header().Set("ETag", app.etags[original])
+		}
 		AssetsServer.ServeHTTP(w, r)
 	}))
 
 	router.Handle("/go/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-		if version, err := r.Cookie("version"); err == nil && version.Value != app.worker.Version {
+		//Queued calls are replayed after the app updates, they must still be handled, see client.Queue
+		var queued = r.Header.Get("Idempotency-Key") != ""
+
+		if version, err := r.Cookie("version"); err == nil && version.Value != app.worker.Version && !queued {
 
 			http.SetCookie(w, &http.Cookie{
 				Name:   "version",
@@ -110,6 +152,12 @@ func (a App) Handler() http.Handler {
 				MaxAge: -1,
 			})
 
+			if strings.Contains(r.Header.Get("Accept"), "application/json") {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"update":true}`))
+				return
+			}
+
 			w.Write([]byte(`if (!seed.production) {
 				throw "";
 			} else {
@@ -122,6 +170,11 @@ func (a App) Handler() http.Handler {
 		client.Handler(w, r, r.URL.Path[4:])
 	}))
 
+	//Live feeds stream their changes, see feed.Live
+	router.Handle("/go/feed/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		feed.Handler(w, r, r.URL.Path[len("/go/feed/"):])
+	}))
+
 	router.Handle("/seed.socket", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if isLocal(r) && a.port == ":0" {
 			localClients++
@@ -133,69 +186,80 @@ func (a App) Handler() http.Handler {
 		}
 	}))
 
-	var manifest = app.manifest.Render()
-	router.Handle("/app.webmanifest", gziphandler.GzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("content-type", "application/json")
-		w.Write(manifest)
-	})))
+	router.Handle("/app.webmanifest", precompress("application/json", app.manifest.Render()))
 
-	router.Handle("/robots.txt", gziphandler.GzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("\n"))
-	})))
+	router.Handle("/robots.txt", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(app.robots(app.origin(r)))
+	}))
 
-	router.Handle("/.well-known/assetlinks.json", gziphandler.GzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if app.pkg != "" {
-			w.Header().Set("Content-Type", "application/json")
-			w.Write([]byte(`[{
+	router.Handle("/sitemap.xml", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Write(app.sitemap(pages, app.origin(r)))
+	}))
+
+	var assetlinks bytes.Buffer
+	if app.pkg != "" {
+		assetlinks.WriteString(`[{
 "relation": ["delegate_permission/common.handle_all_urls"],
 "target" : { "namespace": "android_app", "package_name": "` + app.pkg + `",
-		   "sha256_cert_fingerprints": [`))
+		   "sha256_cert_fingerprints": [`)
 
-			for i, hash := range app.hashes {
-				w.Write([]byte("\"" + hash + "\""))
-				if i < len(app.hashes)-1 {
-					w.Write([]byte(`,`))
-				}
+		for i, hash := range app.hashes {
+			assetlinks.WriteString("\"" + hash + "\"")
+			if i < len(app.hashes)-1 {
+				assetlinks.WriteString(`,`)
 			}
-
-			w.Write([]byte(`] }
-}]`))
-			return
 		}
-	})))
 
-	router.Handle("/index.js", gziphandler.GzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assetlinks.WriteString(`] }
+}]`)
+	}
+	router.Handle("/.well-known/assetlinks.json", precompress("application/json", assetlinks.Bytes()))
+
+	router.Handle("/index.js", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if isLocal(r) {
-			//Don't use a web worker if we are running locally.
-			w.Header().Set("content-type", "text/javascript")
-			w.Write([]byte(`self.addEventListener('install', () => {self.skipWaiting();});`))
+			localWorker.ServeHTTP(w, r)
 		} else {
-			w.Header().Set("content-type", "text/javascript")
-			w.Write(worker)
+			worker.ServeHTTP(w, r)
 		}
-	})))
+	}))
+
+	//Shares are posted to their action, which otherwise serves the document.
+	for _, share := range app.shares {
+		var share = share
+		router.Handle(share.action, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				share.ServeHTTP(w, r)
+				return
+			}
+			index.ServeHTTP(w, r)
+		}))
+	}
+
+	if app.probes != nil {
+		app.probes.handle(router, version)
+	}
+
+	if app.metrics != nil {
+		app.metrics.version = version
+		router.Handle("/metrics", app.metrics)
+	}
 
 	for route, handler := range api.Routes(app.document.Seed) {
 		router.Handle(route, handler)
 	}
 
-	router.Handle("/", gziphandler.GzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	router.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var path, control = r.URL.Path, revalidate
 
-		if content, ok := scripts[r.URL.Path]; ok {
-			w.Header().Set("Content-Type", "text/javascript")
-			fmt.Fprint(w, content)
-			return
+		//Content-hashed paths never change, so they can be cached forever.
+		if original, ok := assets.Original(path); ok {
+			path, control = original, immutable
 		}
 
-		if content, ok := stylesheets[r.URL.Path]; ok {
-			w.Header().Set("Content-Type", "text/css")
-			fmt.Fprint(w, content)
-			return
-		}
-
-		if content, ok := imports[r.URL.Path]; ok {
-			w.Header().Set("Content-Type", "text/javascript")
-			fmt.Fprint(w, content)
+		if resource, ok := statics[path]; ok {
+			resource.cache(control, app.etags[path]).ServeHTTP(w, r)
 			return
 		}
 
@@ -208,8 +272,47 @@ func (a App) Handler() http.Handler {
 			})
 		}
 
-		w.Write(document)
-	})))
+		var served, resource, status, generated = document, index, http.StatusOK, false
+
+		//The service worker caches the shell of the app, rather than a prerendered page.
+		if r.Header.Get(shellHeader) == "" {
+			var p, ok = match(pages, r.URL.Path)
+
+			var missing = !ok && r.URL.Path != "/"
+			if missing {
+				p, ok, status = notFound, true, http.StatusNotFound
+			}
+
+			if ok {
+				served, resource = p.document, p.index
+
+				if _, ok := p.Page.(page.Describer); ok {
+					served, generated = app.described(p, w, r), true
+				}
+				if route, ok := p.initial(r.URL.Path, r.URL.RawQuery, missing); ok {
+					served, generated = embed(served, "seed.route", route), true
+				}
+			}
+		}
+
+		if stateful, ok := app.stateful(w, r, served); ok {
+			dynamic(w, r, stateful, status, "private, no-cache")
+			return
+		}
+
+		if generated {
+			dynamic(w, r, served, status, revalidate)
+			return
+		}
+
+		//The document must always be revalidated, so that new versions are picked up.
+		resource.ServeHTTP(w, r)
+	}))
+
+	var secured = app.security.headers(document, router)
+	if app.accessLog != nil || app.metrics != nil {
+		secured = app.observe(router, secured)
+	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
@@ -218,6 +321,6 @@ func (a App) Handler() http.Handler {
 			http.Redirect(w, r, "https://"+r.Host+r.URL.String(), http.StatusSeeOther)
 		}
 
-		router.ServeHTTP(w, r)
+		secured.ServeHTTP(w, r)
 	})
 }
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"hash/fnv"
 	"math"
@@ -8,8 +9,13 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"runtime"
 	"strings"
+	"syscall"
+	"time"
+
+	"qlova.org/seed/new/feed"
 )
 
 var browsers = []string{
@@ -113,5 +119,46 @@ retry:
 
 	fmt.Printf("\nlaunching %v version %v on http://localhost%v\n", data.name, data.worker.Version, port)
 
-	return http.Serve(listener, handler)
+	var server = http.Server{Handler: handler}
+
+	//Live feeds stream until they are closed, they must not hold up the shutdown.
+	server.RegisterOnShutdown(feed.Shutdown)
+
+	//Drain and then gracefully shutdown on interrupt.
+	var stopped = make(chan error, 1)
+	go func() {
+		var signals = make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		a.Drain()
+
+		//Keep serving until the orchestrator has seen that the app is no longer ready,
+		//a second signal skips the delay.
+		if data.probes != nil && data.probes.Ready != "" {
+			select {
+			case <-time.After(DrainDelay):
+			case <-signals:
+			}
+		}
+		signal.Stop(signals)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+
+		stopped <- server.Shutdown(ctx)
+	}()
+
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+
+	return <-stopped
 }
+
+//ShutdownTimeout is how long Launch waits for active requests to complete when shutting down.
+var ShutdownTimeout = 30 * time.Second
+
+//DrainDelay is how long Launch keeps serving requests after it has been drained, before it shuts down.
+//It only applies when the readiness probe is served and should be longer than the period of the probe.
+var DrainDelay = 10 * time.Second
@@ -0,0 +1,239 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" //decode JPEG icons
+	"image/png"
+	"strings"
+
+	"qlova.org/seed/assets/inbed"
+)
+
+//IconSizes are the sizes of the icons generated by GenerateIcons.
+var IconSizes = []int{48, 72, 96, 128, 144, 152, 192, 384, 512}
+
+//MaskableSizes are the sizes of the maskable icons generated by GenerateIcons.
+var MaskableSizes = []int{192, 512}
+
+//Paths of generated icons that are not listed in the manifest.
+const (
+	AppleTouchIcon = "/apple-touch-icon.png"
+	Favicon        = "/favicon.ico"
+)
+
+//maskableSafeZone is the fraction of a maskable icon that is guaranteed to be visible.
+const maskableSafeZone = 0.8
+
+//GenerateIcons decodes the high-resolution PNG or JPEG image at the given path and generates the standard icon set from it.
+//Returns the icons for the manifest and the encoded files of all generated icons by their path,
+//this includes the AppleTouchIcon and Favicon. Maskable and apple-touch icons are padded with the
+//background color.
+func GenerateIcons(path string, background color.Color) ([]Icon, map[string][]byte, error) {
+	if strings.Contains(path, "?") {
+		path = strings.Split(path, "?")[0]
+	}
+
+	file, err := inbed.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not open icon: %w", err)
+	}
+	defer file.Close()
+
+	source, _, err := image.Decode(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not decode icon %v: %w", path, err)
+	}
+
+	var icons []Icon
+	var files = make(map[string][]byte)
+
+	add := func(path string, img image.Image) error {
+		var buffer bytes.Buffer
+		if err := png.Encode(&buffer, img); err != nil {
+			return fmt.Errorf("could not encode icon %v: %w", path, err)
+		}
+		files[path] = buffer.Bytes()
+		return nil
+	}
+
+	for _, size := range IconSizes {
+		var path = fmt.Sprintf("/icons/icon-%vx%v.png", size, size)
+		if err := add(path, fit(source, size, 1, nil)); err != nil {
+			return nil, nil, err
+		}
+		icons = append(icons, Icon{
+			Source:  path,
+			Sizes:   fmt.Sprint(size, "x", size),
+			Type:    "image/png",
+			Purpose: "any",
+		})
+	}
+
+	for _, size := range MaskableSizes {
+		var path = fmt.Sprintf("/icons/maskable-%vx%v.png", size, size)
+		if err := add(path, fit(source, size, maskableSafeZone, background)); err != nil {
+			return nil, nil, err
+		}
+		icons = append(icons, Icon{
+			Source:  path,
+			Sizes:   fmt.Sprint(size, "x", size),
+			Type:    "image/png",
+			Purpose: "maskable",
+		})
+	}
+
+	//iOS doesn't support transparent icons.
+	if err := add(AppleTouchIcon, fit(source, 180, 1, background)); err != nil {
+		return nil, nil, err
+	}
+
+	favicon, err := ico(source, 16, 32, 48)
+	if err != nil {
+		return nil, nil, err
+	}
+	files[Favicon] = favicon
+
+	return icons, files, nil
+}
+
+//ico encodes the image as a .ico file with PNG encoded images of the given sizes.
+func ico(source image.Image, sizes ...int) ([]byte, error) {
+	var images = make([][]byte, len(sizes))
+	for i, size := range sizes {
+		var buffer bytes.Buffer
+		if err := png.Encode(&buffer, fit(source, size, 1, nil)); err != nil {
+			return nil, fmt.Errorf("could not encode favicon: %w", err)
+		}
+		images[i] = buffer.Bytes()
+	}
+
+	var b bytes.Buffer
+
+	//ICONDIR
+	binary.Write(&b, binary.LittleEndian, []uint16{0, 1, uint16(len(sizes))})
+
+	var offset = 6 + 16*len(sizes)
+
+	//ICONDIRENTRY
+	for i, size := range sizes {
+		b.Write([]byte{byte(size % 256), byte(size % 256), 0, 0})
+		binary.Write(&b, binary.LittleEndian, []uint16{1, 32})
+		binary.Write(&b, binary.LittleEndian, []uint32{uint32(len(images[i])), uint32(offset)})
+		offset += len(images[i])
+	}
+
+	for _, image := range images {
+		b.Write(image)
+	}
+
+	return b.Bytes(), nil
+}
+
+//fit scales the source image to fit within a size*size square, centered and scaled to the given fraction of
+//the square. The rest of the square is filled with the background color, or left transparent if background is nil.
+func fit(source image.Image, size int, fraction float64, background color.Color) *image.NRGBA {
+	var canvas = image.NewNRGBA(image.Rect(0, 0, size, size))
+	if background != nil {
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	}
+
+	var bounds = source.Bounds()
+	var w, h = bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return canvas
+	}
+
+	var inner = int(float64(size) * fraction)
+	var sw, sh = inner, inner
+	if w > h {
+		sh = inner * h / w
+	} else {
+		sw = inner * w / h
+	}
+	if sw < 1 {
+		sw = 1
+	}
+	if sh < 1 {
+		sh = 1
+	}
+
+	var scaled = scale(source, sw, sh)
+	var at = image.Pt((size-sw)/2, (size-sh)/2)
+
+	draw.Draw(canvas, scaled.Bounds().Add(at), scaled, image.Point{}, draw.Over)
+
+	return canvas
+}
+
+//scale resizes the source image to w*h with an area-averaging filter, which is
+//well suited to shrinking high-resolution source images.
+func scale(source image.Image, w, h int) *image.NRGBA {
+	var bounds = source.Bounds()
+	var sw, sh = bounds.Dx(), bounds.Dy()
+
+	var result = image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		var y0 = bounds.Min.Y + y*sh/h
+		var y1 = bounds.Min.Y + (y+1)*sh/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < w; x++ {
+			var x0 = bounds.Min.X + x*sw/w
+			var x1 = bounds.Min.X + (x+1)*sw/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			//Average in premultiplied space, so that transparent pixels don't bleed.
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := source.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+
+			var c = color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			}
+			result.Set(x, y, c)
+		}
+	}
+
+	return result
+}
+
+//ParseColor parses a hex color such as #ffffff, used by the manifest.
+func ParseColor(hex string) (color.Color, error) {
+	var c = color.NRGBA{A: 255}
+
+	var err error
+	switch len(hex) {
+	case 7:
+		_, err = fmt.Sscanf(hex, "#%02x%02x%02x", &c.R, &c.G, &c.B)
+	case 4:
+		_, err = fmt.Sscanf(hex, "#%1x%1x%1x", &c.R, &c.G, &c.B)
+		c.R *= 17
+		c.G *= 17
+		c.B *= 17
+	default:
+		err = fmt.Errorf("invalid color %v", hex)
+	}
+
+	return c, err
+}
@@ -21,19 +21,83 @@ type Icon struct {
 	Source string `json:"src"`
 	Sizes  string `json:"sizes"`
 	Type   string `json:"type,omitempty"`
+
+	//Purpose is "any", "maskable" or "monochrome".
+	Purpose string `json:"purpose,omitempty"`
+}
+
+//Shortcut is a quick link into the app, shown by the OS when the app's icon is long-pressed or right-clicked.
+type Shortcut struct {
+	Name        string `json:"name"`
+	ShortName   string `json:"short_name,omitempty"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+
+	Icons []Icon `json:"icons,omitempty"`
+}
+
+//Screenshot is a screenshot of the app, shown by app stores and install prompts.
+type Screenshot struct {
+	Source string `json:"src"`
+	Sizes  string `json:"sizes,omitempty"`
+	Type   string `json:"type,omitempty"`
+
+	//FormFactor is "wide" or "narrow".
+	FormFactor string `json:"form_factor,omitempty"`
+	Label      string `json:"label,omitempty"`
+}
+
+//ShareFile describes the files that can be shared to the app.
+type ShareFile struct {
+	Name   string   `json:"name"`
+	Accept []string `json:"accept"`
+}
+
+//ShareParams names the parameters of a share.
+type ShareParams struct {
+	Title string      `json:"title,omitempty"`
+	Text  string      `json:"text,omitempty"`
+	URL   string      `json:"url,omitempty"`
+	Files []ShareFile `json:"files,omitempty"`
+}
+
+//ShareTarget registers the app as a target of the OS share sheet.
+type ShareTarget struct {
+	Action  string      `json:"action"`
+	Method  string      `json:"method,omitempty"`
+	Enctype string      `json:"enctype,omitempty"`
+	Params  ShareParams `json:"params"`
+}
+
+//FileHandler registers the app as a handler of files, so that they can be opened with the app.
+type FileHandler struct {
+	Action string `json:"action"`
+
+	//Accept maps mime types to file extensions, ie. {"text/markdown": [".md"]}
+	Accept map[string][]string `json:"accept"`
 }
 
 //Manifest is a webapp manifest.
 type Manifest struct {
+	ID              string `json:"id,omitempty"`
 	Name            string `json:"name"`
 	ShortName       string `json:"short_name"`
 	StartURL        string `json:"start_url"`
+	Scope           string `json:"scope,omitempty"`
 	Display         string `json:"display"`
+	Orientation     string `json:"orientation,omitempty"`
 	BackgroundColor string `json:"background_color"`
 	Description     string `json:"description"`
 	ThemeColor      string `json:"theme_color"`
 
-	Icons []Icon `json:"icons"`
+	Categories []string `json:"categories,omitempty"`
+
+	Icons       []Icon       `json:"icons"`
+	Shortcuts   []Shortcut   `json:"shortcuts,omitempty"`
+	Screenshots []Screenshot `json:"screenshots,omitempty"`
+
+	ShareTarget  *ShareTarget  `json:"share_target,omitempty"`
+	FileHandlers []FileHandler `json:"file_handlers,omitempty"`
 }
 
 //New returns a new webapp manifest.
@@ -109,3 +173,46 @@ func (manifest *Manifest) SetThemeColor(c color.Color) {
 	r, g, b, _ := c.RGBA()
 	manifest.ThemeColor = fmt.Sprintf("#%.2x%.2x%.2x", byte(r), byte(g), byte(b))
 }
+
+//SetID sets the identity of the application, this should never change once the app has been installed.
+func (manifest *Manifest) SetID(id string) {
+	manifest.ID = id
+}
+
+//SetScope sets the navigation scope of the application.
+func (manifest *Manifest) SetScope(scope string) {
+	manifest.Scope = scope
+}
+
+//SetOrientation sets the default orientation of the application, ie. "portrait" or "landscape".
+func (manifest *Manifest) SetOrientation(orientation string) {
+	manifest.Orientation = orientation
+}
+
+//AddCategories adds categories to the application, ie. "productivity".
+func (manifest *Manifest) AddCategories(categories ...string) {
+	manifest.Categories = append(manifest.Categories, categories...)
+}
+
+//AddShortcut adds a shortcut to the application.
+func (manifest *Manifest) AddShortcut(shortcut Shortcut) {
+	manifest.Shortcuts = append(manifest.Shortcuts, shortcut)
+}
+
+//AddScreenshot adds a screenshot of the application.
+func (manifest *Manifest) AddScreenshot(screenshot Screenshot) {
+	if screenshot.Sizes == "" {
+		screenshot.Sizes = getImageDimension(screenshot.Source)
+	}
+	manifest.Screenshots = append(manifest.Screenshots, screenshot)
+}
+
+//SetShareTarget registers the application as a share target.
+func (manifest *Manifest) SetShareTarget(target ShareTarget) {
+	manifest.ShareTarget = &target
+}
+
+//AddFileHandler registers the application as a handler of files.
+func (manifest *Manifest) AddFileHandler(handler FileHandler) {
+	manifest.FileHandlers = append(manifest.FileHandlers, handler)
+}
@@ -0,0 +1,294 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"qlova.org/seed"
+	"qlova.org/seed/client"
+)
+
+//buckets are the upper bounds of the latency histograms, in seconds.
+var buckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+//sockets is the number of open development sockets.
+var sockets int64
+
+//LogRequests logs each request that the app handles to the writer, as a line of JSON with the time, method,
+//path, status, latency, bytes written and the name of the Go function called by a remote procedure call.
+func LogRequests(w io.Writer) seed.Option {
+	return seed.Mutate(func(a *app) {
+		a.accessLog = w
+	})
+}
+
+//ServeMetrics serves request counts and latencies per route and per remote procedure call
+//at /metrics, in the Prometheus text format.
+func ServeMetrics() seed.Option {
+	return seed.Mutate(func(a *app) {
+		if a.metrics == nil {
+			a.metrics = newMetrics()
+		}
+	})
+}
+
+//Gauge adds a gauge to the metrics of the app, the value is read whenever the metrics are scraped, ie.
+//	app.Gauge("push_subscriptions", "Number of push subscriptions.", func() float64 {
+//		return float64(len(store.Subscriptions()))
+//	})
+func Gauge(name, help string, value func() float64) seed.Option {
+	return seed.Mutate(func(a *app) {
+		if a.metrics == nil {
+			a.metrics = newMetrics()
+		}
+		a.metrics.gauges = append(a.metrics.gauges, gauge{name, help, value})
+	})
+}
+
+type gauge struct {
+	name, help string
+	value      func() float64
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(seconds float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	for i, bound := range buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+//metrics of the requests handled by the app.
+type metrics struct {
+	mutex sync.Mutex
+
+	//requests are counted by route, method and status.
+	requests map[[3]string]uint64
+	latency  map[string]*histogram
+
+	//calls are counted by function and status.
+	calls       map[[2]string]uint64
+	callLatency map[string]*histogram
+
+	gauges []gauge
+
+	version string
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:    make(map[[3]string]uint64),
+		latency:     make(map[string]*histogram),
+		calls:       make(map[[2]string]uint64),
+		callLatency: make(map[string]*histogram),
+	}
+}
+
+func (m *metrics) observe(route, method, function string, status int, duration time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var code = strconv.Itoa(status)
+
+	m.requests[[3]string{route, method, code}]++
+	if m.latency[route] == nil {
+		m.latency[route] = new(histogram)
+	}
+	m.latency[route].observe(duration.Seconds())
+
+	if function != "" {
+		m.calls[[2]string{function, code}]++
+		if m.callLatency[function] == nil {
+			m.callLatency[function] = new(histogram)
+		}
+		m.callLatency[function].observe(duration.Seconds())
+	}
+}
+
+//label returns a Prometheus label pair.
+func label(name, value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, "\n", `\n`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return name + `="` + value + `"`
+}
+
+func writeHistograms(w io.Writer, name, help, key string, histograms map[string]*histogram) {
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v histogram\n", name, help, name)
+
+	var keys = make([]string, 0, len(histograms))
+	for k := range histograms {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		var h = histograms[k]
+		for i, bound := range buckets {
+			fmt.Fprintf(w, "%v_bucket{%v,le=\"%v\"} %v\n", name, label(key, k), bound, h.counts[i])
+		}
+		fmt.Fprintf(w, "%v_bucket{%v,le=\"+Inf\"} %v\n", name, label(key, k), h.count)
+		fmt.Fprintf(w, "%v_sum{%v} %v\n", name, label(key, k), h.sum)
+		fmt.Fprintf(w, "%v_count{%v} %v\n", name, label(key, k), h.count)
+	}
+}
+
+//ServeHTTP writes the metrics in the Prometheus text format.
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	var b = bufio.NewWriter(w)
+	defer b.Flush()
+
+	fmt.Fprintf(b, "# HELP seed_build_info Build information of the app.\n# TYPE seed_build_info gauge\n")
+	fmt.Fprintf(b, "seed_build_info{%v,%v} 1\n", label("version", m.version), label("goversion", runtime.Version()))
+
+	fmt.Fprintf(b, "# HELP seed_socket_connections Open development sockets.\n# TYPE seed_socket_connections gauge\n")
+	fmt.Fprintf(b, "seed_socket_connections %v\n", atomic.LoadInt64(&sockets))
+
+	for _, g := range m.gauges {
+		fmt.Fprintf(b, "# HELP %v %v\n# TYPE %v gauge\n%v %v\n", g.name, g.help, g.name, g.name, g.value())
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	fmt.Fprintf(b, "# HELP seed_http_requests_total Requests handled by the app.\n# TYPE seed_http_requests_total counter\n")
+	var requests = make([][3]string, 0, len(m.requests))
+	for k := range m.requests {
+		requests = append(requests, k)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return strings.Join(requests[i][:], " ") < strings.Join(requests[j][:], " ")
+	})
+	for _, k := range requests {
+		fmt.Fprintf(b, "seed_http_requests_total{%v,%v,%v} %v\n", label("route", k[0]), label("method", k[1]), label("code", k[2]), m.requests[k])
+	}
+
+	writeHistograms(b, "seed_http_request_duration_seconds", "Latency of requests handled by the app.", "route", m.latency)
+
+	fmt.Fprintf(b, "# HELP seed_rpc_calls_total Remote procedure calls handled by the app.\n# TYPE seed_rpc_calls_total counter\n")
+	var calls = make([][2]string, 0, len(m.calls))
+	for k := range m.calls {
+		calls = append(calls, k)
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		return strings.Join(calls[i][:], " ") < strings.Join(calls[j][:], " ")
+	})
+	for _, k := range calls {
+		fmt.Fprintf(b, "seed_rpc_calls_total{%v,%v} %v\n", label("function", k[0]), label("code", k[1]), m.calls[k])
+	}
+
+	writeHistograms(b, "seed_rpc_call_duration_seconds", "Latency of remote procedure calls handled by the app.", "function", m.callLatency)
+}
+
+//recorder records the status and size of a response.
+type recorder struct {
+	http.ResponseWriter
+
+	status int
+	bytes  int
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+//Hijack is required by the development socket.
+func (r *recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response does not support hijacking")
+	}
+	r.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+//Flush implements http.Flusher.
+func (r *recorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+//observe returns a handler that logs and measures each request handled by next,
+//requests are grouped by the route that they match in the router.
+func (app app) observe(router *http.ServeMux, next http.Handler) http.Handler {
+	var logs sync.Mutex
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var start = time.Now()
+		var response = recorder{ResponseWriter: w}
+
+		next.ServeHTTP(&response, r)
+
+		var duration = time.Since(start)
+		if response.status == 0 {
+			response.status = http.StatusOK
+		}
+
+		_, route := router.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		var function string
+		if strings.HasPrefix(r.URL.Path, "/go/") {
+			function, _ = client.FunctionName(r.URL.Path[4:])
+		}
+
+		if app.metrics != nil {
+			app.metrics.observe(route, r.Method, function, response.status, duration)
+		}
+
+		if app.accessLog != nil {
+			line, _ := json.Marshal(struct {
+				Time     time.Time `json:"time"`
+				Method   string    `json:"method"`
+				Path     string    `json:"path"`
+				Status   int       `json:"status"`
+				Latency  float64   `json:"latency_ms"`
+				Bytes    int       `json:"bytes"`
+				Function string    `json:"rpc,omitempty"`
+			}{start, r.Method, r.URL.Path, response.status, float64(duration) / float64(time.Millisecond), response.bytes, function})
+
+			logs.Lock()
+			app.accessLog.Write(append(line, '\n'))
+			logs.Unlock()
+		}
+	})
+}
@@ -1,6 +1,8 @@
 package app
 
 import (
+	"bytes"
+	"io"
 	"regexp"
 
 	"github.com/tdewolff/minify/v2"
@@ -13,6 +15,15 @@ import (
 )
 
 func mini(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	if err := miniTo(&b, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+//miniTo minifies the HTML document from r into w.
+func miniTo(w io.Writer, r io.Reader) error {
 	minifier := minify.New()
 	minifier.Add("text/html", &html.Minifier{
 		KeepDocumentTags: true,
@@ -23,10 +34,30 @@ func mini(data []byte) ([]byte, error) {
 	minifier.AddFuncRegexp(regexp.MustCompile("[/+]json$"), json.Minify)
 	minifier.AddFuncRegexp(regexp.MustCompile("[/+]xml$"), xml.Minify)
 
-	minified, err := minifier.Bytes("text/html", data)
-	if err != nil {
-		return nil, err
+	return minifier.Minify("text/html", w, r)
+}
+
+//minified streams the rendered document through fingerprinting and the minifier, so that the
+//document is only buffered once. Falls back to the unminified document if it can't be minified.
+func (app app) minified() []byte {
+	rendered, w := io.Pipe()
+	go func() {
+		w.CloseWithError(app.document.RenderTo(w))
+	}()
+
+	rewritten, fw := io.Pipe()
+	go func() {
+		fw.CloseWithError(fingerprintTo(fw, rendered))
+	}()
+
+	var b bytes.Buffer
+	if err := miniTo(&b, rewritten); err != nil {
+		//Stop the renderer.
+		rewritten.CloseWithError(err)
+		rendered.CloseWithError(err)
+
+		return fingerprinted(app.document.Render())
 	}
 
-	return minified, nil
+	return b.Bytes()
 }
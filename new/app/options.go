@@ -8,6 +8,8 @@ import (
 	"qlova.org/seed"
 	"qlova.org/seed/assets"
 	"qlova.org/seed/client"
+	"qlova.org/seed/new/app/manifest"
+	"qlova.org/seed/new/app/service"
 	"qlova.org/seed/new/page"
 	"qlova.org/seed/use/css"
 )
@@ -31,6 +33,31 @@ func SetLoadingPage(p page.Page) seed.Option {
 	})
 }
 
+//SetOfflinePage sets the page that is shown when the app navigates to a
+//page that has not been cached while the network is unavailable.
+func SetOfflinePage(p page.Page) seed.Option {
+	return seed.Mutate(func(a *app) {
+		a.offlinePage = p
+	})
+}
+
+//Cache adds caching rules to the service worker of the app, ie.
+//	app.Cache(service.Route{Prefix: "/api/", Strategy: service.NetworkFirst})
+func Cache(routes ...service.Route) seed.Option {
+	return seed.Mutate(func(a *app) {
+		for _, route := range routes {
+			a.worker.Handle(route)
+		}
+	})
+}
+
+//SetManifest edits the web app manifest of the app, ie. to add shortcuts, screenshots or categories.
+func SetManifest(edit func(*manifest.Manifest)) seed.Option {
+	return seed.Mutate(func(a *app) {
+		edit(&a.manifest)
+	})
+}
+
 //Head sets the options of the head of the app.
 func Head(o ...seed.Option) seed.Option {
 	return seed.Mutate(func(a *app) {
@@ -79,7 +106,8 @@ func SetColor(col color.Color) seed.Option {
 	})
 }
 
-//SetIcon sets the icon of the app.
+//SetIcon sets the icon of the app, this should be a high-resolution square PNG or JPEG image.
+//The standard set of icons (including maskable icons, an apple-touch-icon and favicon.ico) are generated from it.
 func SetIcon(icon string) seed.Option {
 	icon = assets.Path(icon)
 
@@ -107,7 +135,7 @@ func SetIcon(icon string) seed.Option {
 		case client.Undo:
 			fmt.Fprintf(q, `document.getElementById('dynamic-favicon').removeChild(oldLink);`)
 		default:
-			app.manifest.SetIcon(icon)
+			app.icon = icon
 		}
 
 		c.Save(app)
@@ -0,0 +1,295 @@
+package app
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"qlova.org/seed"
+	"qlova.org/seed/assets"
+	"qlova.org/seed/client"
+	"qlova.org/seed/new/html/link"
+	"qlova.org/seed/new/html/meta"
+	"qlova.org/seed/new/html/title"
+	"qlova.org/seed/new/page"
+	"qlova.org/seed/use/html"
+	"qlova.org/seed/use/html/attr"
+)
+
+//SetURL sets the public url of the app, ie. "https://example.com", it is used for the canonical
+//links and preview images of the prerendered pages, the sitemap and robots.txt.
+//By default the url is taken from the host of each request.
+func SetURL(url string) seed.Option {
+	return seed.Mutate(func(a *app) {
+		a.url = strings.TrimSuffix(url, "/")
+	})
+}
+
+//prerendered is a page that is served with its own document, where the page is already visible.
+type prerendered struct {
+	page.Route
+
+	//rendered is the document before it is described and minified.
+	rendered []byte
+
+	document []byte
+	index    static
+}
+
+//routes returns the pages of the app that have a url path, custom routes first and then the deepest paths.
+func (app app) routes() []page.Route {
+	var all = page.Routes(app.document.Body)
+
+	var routes []page.Route
+	for _, route := range all {
+		if route.Path != "" {
+			routes = append(routes, route)
+		}
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].Path) > len(routes[j].Path)
+	})
+
+	var aliases []page.Route
+	for _, alias := range app.aliases {
+		for _, route := range all {
+			if reflect.TypeOf(route.Page) == reflect.TypeOf(alias.page) {
+				route.Path = alias.pattern
+				aliases = append(aliases, route)
+				break
+			}
+		}
+	}
+
+	return append(aliases, routes...)
+}
+
+//prerender prerenders the routed pages of the app from the rendered document, along with the not found page.
+func (app app) prerender(rendered []byte) (pages []prerendered, missing prerendered) {
+	var routes = app.routes()
+
+	//Pages with more than one route share their documents.
+	var documents = make(map[string]prerendered)
+
+	var render = func(route page.Route) prerendered {
+		var prerendered = prerendered{Route: route}
+
+		var canonical string
+		if route.Path != "" && !route.Args && !strings.ContainsAny(route.Path, "{*") {
+			canonical = app.url
+		}
+
+		var key = page.ID(route.Page)
+		if canonical != "" {
+			key += " " + route.Path
+		}
+		if existing, ok := documents[key]; ok {
+			existing.Route = route
+			return existing
+		}
+
+		prerendered.rendered = fingerprinted(app.styled(route, route.Prerender(rendered)))
+
+		var document = app.describe(prerendered.rendered, route.Meta, canonical, route.Path)
+		if minified, err := mini(document); err == nil {
+			document = minified
+		}
+
+		prerendered.document = document
+		prerendered.index = precompress("text/html; charset=utf-8", document).cache(revalidate, strconv.Quote(assets.Hash(document)))
+
+		documents[key] = prerendered
+		return prerendered
+	}
+
+	pages = make([]prerendered, 0, len(routes))
+	for _, route := range routes {
+		pages = append(pages, render(route))
+	}
+
+	for _, route := range page.Routes(app.document.Body) {
+		if reflect.TypeOf(route.Page) == reflect.TypeOf(app.notFoundPage) {
+			route.Path = ""
+			missing = render(route)
+		}
+	}
+
+	return pages, missing
+}
+
+//styled links the stylesheet of the chunk of a lazy page, so that the prerendered page is styled before it is loaded.
+func (app app) styled(route page.Route, document []byte) []byte {
+	var stylesheet = strings.TrimSuffix(route.Chunk, ".js") + ".css"
+	if _, ok := app.chunks[stylesheet]; route.Chunk == "" || !ok {
+		return document
+	}
+
+	var insert = bytes.Index(document, []byte("</head>"))
+	if insert < 0 {
+		return document
+	}
+
+	var b bytes.Buffer
+	b.Write(document[:insert])
+	b.Write(html.Render(link.New(
+		attr.Set("rel", "stylesheet"),
+		attr.Set("href", stylesheet),
+	)))
+	b.Write(document[insert:])
+
+	return b.Bytes()
+}
+
+//match returns the prerendered page that the url path is routed to.
+func match(pages []prerendered, path string) (prerendered, bool) {
+	for _, p := range pages {
+		if p.Match(path) {
+			return p, true
+		}
+	}
+	return prerendered{}, false
+}
+
+//described returns the document of the page described for the request, the page must be a page.Describer.
+func (app app) described(p prerendered, w http.ResponseWriter, r *http.Request) []byte {
+	var described = p.Page.(page.Describer).Describe(client.NewRequest(w, r))
+
+	if described.Title == "" {
+		described.Title = p.Meta.Title
+	}
+	if described.Description == "" {
+		described.Description = p.Meta.Description
+	}
+	if described.Image == "" {
+		described.Image = p.Meta.Image
+	}
+
+	//The policy allows the inline scripts of the minified document.
+	var document = app.describe(p.rendered, described, app.origin(r), r.URL.Path)
+	if minified, err := mini(document); err == nil {
+		document = minified
+	}
+	return document
+}
+
+//describe adds the title, description and Open Graph meta of a page to the rendered document.
+//The canonical url of the page is only linked when the origin is known.
+func (app app) describe(rendered []byte, described page.Meta, origin, path string) []byte {
+	if described.Title != "" {
+		rendered = bytes.Replace(rendered, html.Render(title.New(app.name)), html.Render(title.New(described.Title)), 1)
+	} else {
+		described.Title = app.name
+	}
+
+	if described.Description != "" && app.description != "" {
+		rendered = bytes.Replace(rendered, html.Render(meta.Description(app.description)), nil, 1)
+	}
+	if described.Description == "" {
+		described.Description = app.description
+	}
+
+	if described.Image != "" && strings.HasPrefix(described.Image, "/") {
+		if origin == "" {
+			described.Image = ""
+		} else {
+			described.Image = origin + assets.Fingerprinted(described.Image)
+		}
+	}
+
+	var head = seed.New(
+		seed.If(described.Description != "" && described.Description != app.description,
+			meta.Description(described.Description),
+		),
+
+		meta.Property("og:type", "website"),
+		meta.Property("og:site_name", app.name),
+		meta.Property("og:title", described.Title),
+
+		seed.If(described.Description != "",
+			meta.Property("og:description", described.Description),
+		),
+		seed.If(described.Image != "",
+			meta.Property("og:image", described.Image),
+		),
+
+		seed.If(origin != "",
+			meta.Property("og:url", origin+path),
+			link.New(
+				attr.Set("rel", "canonical"),
+				attr.Set("href", origin+path),
+			),
+		),
+	)
+
+	var insert = bytes.Index(rendered, []byte("</head>"))
+	if insert < 0 {
+		return rendered
+	}
+
+	var b bytes.Buffer
+	b.Write(rendered[:insert])
+	b.Write(html.Render(head))
+	b.Write(rendered[insert:])
+
+	return b.Bytes()
+}
+
+//origin returns the public url of the app for the request.
+func (app app) origin(r *http.Request) string {
+	if app.url != "" {
+		return app.url
+	}
+
+	var scheme = "https"
+	if r.TLS == nil && (isLocal(r) || r.Header.Get("X-Forwarded-Proto") == "http") {
+		scheme = "http"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+//sitemap returns the sitemap of the prerendered pages.
+func (app app) sitemap(pages []prerendered, origin string) []byte {
+	var paths = make([]string, 0, len(pages))
+	for _, p := range pages {
+		if strings.ContainsAny(p.Path, "{*") {
+			continue
+		}
+		if T := reflect.TypeOf(p.Page); T == reflect.TypeOf(app.offlinePage) || T == reflect.TypeOf(app.notFoundPage) {
+			continue
+		}
+		paths = append(paths, p.Path)
+	}
+	sort.Strings(paths)
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	for _, path := range paths {
+		b.WriteString("<url><loc>")
+		xml.EscapeText(&b, []byte(origin+path))
+		b.WriteString("</loc></url>\n")
+	}
+	b.WriteString("</urlset>\n")
+
+	return b.Bytes()
+}
+
+//robots returns the robots.txt of the app, which keeps crawlers away from remote procedure calls.
+func (app app) robots(origin string) []byte {
+	var b bytes.Buffer
+	b.WriteString("User-agent: *\n")
+	b.WriteString("Disallow: /go/\n")
+	if app.worker.Offline != "" {
+		b.WriteString("Disallow: " + app.worker.Offline + "\n")
+	}
+	if origin != "" {
+		b.WriteString("\nSitemap: " + origin + "/sitemap.xml\n")
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"qlova.org/seed"
+)
+
+//Probes are the paths of the health, readiness and version endpoints of the app,
+//an empty path disables the endpoint.
+type Probes struct {
+	//Health responds with 200 OK whenever the app is serving.
+	Health string
+
+	//Ready responds with 200 OK when all of the readiness checks pass and
+	//the app is not shutting down, otherwise 503 Service Unavailable.
+	Ready string
+
+	//Version responds with the version of the app, the Go build info and the start time.
+	Version string
+}
+
+//DefaultProbes are the conventional paths of the probes.
+var DefaultProbes = Probes{
+	Health:  "/healthz",
+	Ready:   "/readyz",
+	Version: "/version",
+}
+
+//ReadyTimeout is how long the readiness checks have to complete.
+var ReadyTimeout = 5 * time.Second
+
+type check struct {
+	name  string
+	check func(context.Context) error
+}
+
+//probes holds the state of the probes, shared between copies of the app.
+type probes struct {
+	Probes
+
+	checks []check
+
+	draining int32
+	started  time.Time
+}
+
+//ServeProbes serves the probes of the app at the given paths, ie.
+//	app.ServeProbes(app.DefaultProbes)
+func ServeProbes(paths Probes) seed.Option {
+	return seed.Mutate(func(a *app) {
+		if a.probes == nil {
+			a.probes = new(probes)
+		}
+		a.probes.Probes = paths
+	})
+}
+
+//ReadyCheck adds a readiness check to the app, the app is not ready while the check returns an error, ie.
+//	app.ReadyCheck("database", db.PingContext)
+func ReadyCheck(name string, ready func(context.Context) error) seed.Option {
+	return seed.Mutate(func(a *app) {
+		if a.probes == nil {
+			a.probes = new(probes)
+		}
+		a.probes.checks = append(a.probes.checks, check{name, ready})
+	})
+}
+
+//Drain marks the app as shutting down, so that it is no longer ready and stops receiving new traffic.
+//Launch drains the app when it receives an interrupt or terminate signal.
+func (a App) Drain() {
+	var app app
+	a.Load(&app)
+
+	if app.probes != nil {
+		atomic.StoreInt32(&app.probes.draining, 1)
+	}
+}
+
+//handle adds the probes to the router.
+func (p *probes) handle(router *http.ServeMux, version string) {
+	p.started = time.Now()
+
+	if p.Health != "" {
+		router.Handle(p.Health, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "no-store")
+			w.Write([]byte("ok\n"))
+		}))
+	}
+
+	if p.Ready != "" {
+		router.Handle(p.Ready, http.HandlerFunc(p.ready))
+	}
+
+	if p.Version != "" {
+		var info = struct {
+			Version string    `json:"version"`
+			Go      string    `json:"go"`
+			Module  string    `json:"module,omitempty"`
+			Build   string    `json:"build,omitempty"`
+			Started time.Time `json:"started"`
+		}{
+			Version: version,
+			Go:      runtime.Version(),
+			Started: p.started,
+		}
+		if build, ok := debug.ReadBuildInfo(); ok {
+			info.Module = build.Main.Path
+			info.Build = build.Main.Version
+		}
+
+		encoded, _ := json.Marshal(info)
+
+		router.Handle(p.Version, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(encoded)
+		}))
+	}
+}
+
+//ready runs the readiness checks.
+func (p *probes) ready(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+
+	if atomic.LoadInt32(&p.draining) == 1 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"ready":false,"draining":true}`))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), ReadyTimeout)
+	defer cancel()
+
+	var failures = make(map[string]string)
+	for _, c := range p.checks {
+		if err := c.check(ctx); err != nil {
+			failures[c.name] = err.Error()
+		}
+	}
+
+	if len(failures) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(struct {
+			Ready  bool              `json:"ready"`
+			Failed map[string]string `json:"failed"`
+		}{false, failures})
+		return
+	}
+
+	w.Write([]byte(`{"ready":true}`))
+}
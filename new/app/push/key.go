@@ -0,0 +1,78 @@
+package push
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+
+	"qlova.org/seed"
+)
+
+var key struct {
+	sync.Mutex
+	private *ecdsa.PrivateKey
+}
+
+//Key returns the VAPID key used to identify the app to push services. Reads the key from the VAPID_KEY env
+//(the base64url encoded private key). Will create a key and store it in seed.Dir if the env is not set.
+//Panics if the key is invalid, rather than replacing it and breaking every existing subscription.
+func Key() *ecdsa.PrivateKey {
+	key.Lock()
+	defer key.Unlock()
+
+	if key.private != nil {
+		return key.private
+	}
+
+	//Existing subscriptions break when the key changes, so an invalid key is never replaced.
+	var d []byte
+	if env := os.Getenv("VAPID_KEY"); env != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(env)
+		if err != nil || len(decoded) != 32 {
+			panic("push: VAPID_KEY must be a base64url encoded P-256 private key")
+		}
+		d = decoded
+	} else if stored, err := ioutil.ReadFile(seed.Dir + "/vapid.key"); err == nil {
+		if len(stored) != 32 {
+			panic("push: " + seed.Dir + "/vapid.key is not a P-256 private key")
+		}
+		d = stored
+	} else if !os.IsNotExist(err) {
+		panic("push: could not read VAPID key: " + err.Error())
+	} else {
+		private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			panic("push: could not generate VAPID key: " + err.Error())
+		}
+
+		d = make([]byte, 32)
+		fill(private.D, d)
+
+		if err := ioutil.WriteFile(seed.Dir+"/vapid.key", d, 0600); err != nil {
+			log.Println("push: could not store VAPID key, push subscriptions will break when the app restarts:", err)
+		}
+	}
+
+	key.private = newPrivateKey(d)
+	return key.private
+}
+
+//PublicKey returns the base64url encoded public VAPID key, this is the applicationServerKey of a subscription.
+func PublicKey() string {
+	var private = Key()
+	return base64.RawURLEncoding.EncodeToString(elliptic.Marshal(elliptic.P256(), private.X, private.Y))
+}
+
+func newPrivateKey(d []byte) *ecdsa.PrivateKey {
+	var private = new(ecdsa.PrivateKey)
+	private.Curve = elliptic.P256()
+	private.D = new(big.Int).SetBytes(d)
+	private.X, private.Y = private.Curve.ScalarBaseMult(d)
+	return private
+}
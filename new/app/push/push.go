@@ -0,0 +1,164 @@
+//Package push provides Web Push notifications for apps.
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"qlova.org/seed"
+	"qlova.org/seed/client"
+	"qlova.org/seed/client/clientrpc"
+	"qlova.org/seed/use/js"
+)
+
+//Subscription is a push subscription of a client.
+type Subscription struct {
+	Endpoint string `json:"endpoint"`
+
+	Keys struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+//Store persists push subscriptions.
+type Store interface {
+	Save(clientrpc.Request, Subscription) error
+	Delete(clientrpc.Request, Subscription) error
+}
+
+//Memory is an in-memory Store, subscriptions are lost when the app restarts.
+type Memory struct {
+	mutex         sync.Mutex
+	subscriptions map[string]Subscription
+}
+
+//Save implements Store.
+func (m *Memory) Save(r clientrpc.Request, s Subscription) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.subscriptions == nil {
+		m.subscriptions = make(map[string]Subscription)
+	}
+	m.subscriptions[s.Endpoint] = s
+	return nil
+}
+
+//Delete implements Store.
+func (m *Memory) Delete(r clientrpc.Request, s Subscription) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.subscriptions, s.Endpoint)
+	return nil
+}
+
+//Subscriptions returns the subscriptions in the store.
+func (m *Memory) Subscriptions() []Subscription {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var subscriptions = make([]Subscription, 0, len(m.subscriptions))
+	for _, s := range m.subscriptions {
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions
+}
+
+func decode(subscription string) (s Subscription, err error) {
+	if err := json.Unmarshal([]byte(subscription), &s); err != nil {
+		return s, fmt.Errorf("push: invalid subscription: %w", err)
+	}
+	return s, nil
+}
+
+//Subscribe returns a script that asks the client for permission to show notifications
+//and then subscribes the client to push messages, saving the subscription to the store.
+//Nothing is saved if the client denies permission or doesn't support push messages.
+func Subscribe(store Store) client.Script {
+	return js.Script(func(q js.Ctx) {
+		var subscription = js.NewValue(client.Unique())
+
+		fmt.Fprintf(q, `let %v = await seed.push.subscribe(%v);`, subscription, strconv.Quote(PublicKey()))
+		fmt.Fprintf(q, `if (%v) {`, subscription)
+		q(client.Run(func(r clientrpc.Request, subscription string) error {
+			s, err := decode(subscription)
+			if err != nil {
+				return err
+			}
+			return store.Save(r, s)
+		}, subscription))
+		q(`}`)
+	})
+}
+
+//Unsubscribe returns a script that unsubscribes the client from push messages and deletes the subscription from the store.
+func Unsubscribe(store Store) client.Script {
+	return js.Script(func(q js.Ctx) {
+		var subscription = js.NewValue(client.Unique())
+
+		fmt.Fprintf(q, `let %v = await seed.push.unsubscribe();`, subscription)
+		fmt.Fprintf(q, `if (%v) {`, subscription)
+		q(client.Run(func(r clientrpc.Request, subscription string) error {
+			s, err := decode(subscription)
+			if err != nil {
+				return err
+			}
+			return store.Delete(r, s)
+		}, subscription))
+		q(`}`)
+	})
+}
+
+func init() {
+	client.RegisterRenderer(func(c seed.Seed) []byte {
+		return []byte(`
+seed.push = {};
+
+seed.push.supported = function() {
+	return ('serviceWorker' in navigator) && ('PushManager' in window) && ('Notification' in window);
+};
+
+seed.push.subscribe = async function(key) {
+	if (!seed.push.supported()) return null;
+	if (await Notification.requestPermission() != "granted") return null;
+
+	let raw = atob(key.replace(/-/g, '+').replace(/_/g, '/'));
+	let applicationServerKey = new Uint8Array(raw.length);
+	for (let i = 0; i < raw.length; i++) applicationServerKey[i] = raw.charCodeAt(i);
+
+	let registration = await navigator.serviceWorker.ready;
+	let subscription = await registration.pushManager.getSubscription();
+	if (!subscription) {
+		subscription = await registration.pushManager.subscribe({
+			userVisibleOnly: true,
+			applicationServerKey: applicationServerKey,
+		});
+	}
+	return subscription.toJSON();
+};
+
+seed.push.unsubscribe = async function() {
+	if (!seed.push.supported()) return null;
+
+	let registration = await navigator.serviceWorker.ready;
+	let subscription = await registration.pushManager.getSubscription();
+	if (!subscription) return null;
+
+	await subscription.unsubscribe();
+	return subscription.toJSON();
+};
+
+//The service worker asks us to go to a page when a notification is clicked.
+if ('serviceWorker' in navigator) {
+	navigator.serviceWorker.addEventListener("message", async function(event) {
+		if (!event.data || event.data.type != "seed.push") return;
+		if (event.data.page && seed.goto) await seed.goto(event.data.page);
+	});
+}
+`)
+	})
+}
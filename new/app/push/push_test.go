@@ -0,0 +1,182 @@
+package push
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+//decrypt is the user agent side of RFC 8291.
+func decrypt(t *testing.T, body []byte, private *ecdsa.PrivateKey, authSecret []byte) []byte {
+	var curve = elliptic.P256()
+
+	var salt = body[:16]
+	var rs = binary.BigEndian.Uint32(body[16:20])
+	var idlen = int(body[20])
+	var asPublic = body[21 : 21+idlen]
+	var ciphertext = body[21+idlen:]
+
+	if rs != recordSize {
+		t.Fatalf("unexpected record size %v", rs)
+	}
+
+	x, y := elliptic.Unmarshal(curve, asPublic)
+	sx, _ := curve.ScalarMult(x, y, private.D.Bytes())
+	var secret = make([]byte, 32)
+	fill(sx, secret)
+
+	var uaPublic = elliptic.Marshal(curve, private.X, private.Y)
+
+	var prkKey = hmacSHA256(authSecret, secret)
+	var ikm = hmacSHA256(prkKey, []byte("WebPush: info\x00"), uaPublic, asPublic, []byte{1})
+	var prk = hmacSHA256(salt, ikm)
+	var cek = hmacSHA256(prk, []byte("Content-Encoding: aes128gcm\x00\x01"))[:16]
+	var nonce = hmacSHA256(prk, []byte("Content-Encoding: nonce\x00\x01"))[:12]
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plaintext[len(plaintext)-1] != 2 {
+		t.Fatal("missing padding delimiter")
+	}
+
+	return plaintext[:len(plaintext)-1]
+}
+
+//verify checks the VAPID Authorization header.
+func verify(t *testing.T, authorization string) {
+	if !strings.HasPrefix(authorization, "vapid t=") {
+		t.Fatalf("invalid authorization %v", authorization)
+	}
+
+	var parts = strings.Split(strings.TrimPrefix(authorization, "vapid t="), ", k=")
+	if parts[1] != PublicKey() {
+		t.Fatal("authorization has the wrong public key")
+	}
+
+	var jwt = strings.Split(parts[0], ".")
+	signature, _ := base64.RawURLEncoding.DecodeString(jwt[2])
+
+	var digest = sha256.Sum256([]byte(jwt[0] + "." + jwt[1]))
+	var r, s = new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+
+	if !ecdsa.Verify(&Key().PublicKey, digest[:], r, s) {
+		t.Fatal("invalid VAPID signature")
+	}
+}
+
+func init() {
+	//Don't write a vapid.key during tests.
+	var vapidKey = make([]byte, 32)
+	vapidKey[31] = 42
+	os.Setenv("VAPID_KEY", base64.RawURLEncoding.EncodeToString(vapidKey))
+}
+
+func TestSend(t *testing.T) {
+	ua, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var authSecret = make([]byte, 16)
+	rand.Read(authSecret)
+
+	var received []byte
+
+	//Stand-in for a push service.
+	var endpoint = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "aes128gcm" {
+			t.Error("wrong content-encoding")
+		}
+		verify(t, r.Header.Get("Authorization"))
+
+		body, _ := ioutil.ReadAll(r.Body)
+		received = decrypt(t, body, ua, authSecret)
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer endpoint.Close()
+
+	var subscription Subscription
+	subscription.Endpoint = endpoint.URL
+	subscription.Keys.P256dh = base64.RawURLEncoding.EncodeToString(elliptic.Marshal(elliptic.P256(), ua.X, ua.Y))
+	subscription.Keys.Auth = base64.RawURLEncoding.EncodeToString(authSecret)
+
+	if err := Send(subscription, Notification{Title: "Hello", Body: "World"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var message struct {
+		Title, Body string
+	}
+	if err := json.Unmarshal(received, &message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Title != "Hello" || message.Body != "World" {
+		t.Fatalf("unexpected message %s", received)
+	}
+}
+
+//TestEncrypt checks encrypt against the example of RFC 8291 Appendix A.
+func TestEncrypt(t *testing.T) {
+	var decode = func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	var subscription Subscription
+	subscription.Keys.P256dh = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+	subscription.Keys.Auth = "BTBZMqHH6r4Tts7J_aSIgg"
+
+	//The application server private key, followed by the salt.
+	var random = bytes.NewReader(append(
+		decode("yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"),
+		decode("DGv6ra1nlYgDCS1FRnbzlw")...,
+	))
+
+	body, err := encrypt(subscription, []byte("When I grow up, I want to be a watermelon"), random)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	if encoded := base64.RawURLEncoding.EncodeToString(body); encoded != expected {
+		t.Fatalf("expected %v, got %v", expected, encoded)
+	}
+}
+
+func TestSendExpired(t *testing.T) {
+	var endpoint = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer endpoint.Close()
+
+	ua, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	var subscription Subscription
+	subscription.Endpoint = endpoint.URL
+	subscription.Keys.P256dh = base64.RawURLEncoding.EncodeToString(elliptic.Marshal(elliptic.P256(), ua.X, ua.Y))
+	subscription.Keys.Auth = base64.RawURLEncoding.EncodeToString(make([]byte, 16))
+
+	if err := Send(subscription, Notification{Title: "Hello"}); err != ErrExpired {
+		t.Fatalf("expected ErrExpired, got %v", err)
+	}
+}
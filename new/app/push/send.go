@@ -0,0 +1,267 @@
+package push
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"qlova.org/seed/new/page"
+)
+
+//ErrExpired is returned by Send when the subscription is no longer valid and should be deleted.
+var ErrExpired = errors.New("push: subscription has expired or unsubscribed")
+
+//recordSize is the record size of encrypted payloads, payloads must fit within a single record.
+const recordSize = 4096
+
+//Notification is a notification that is shown by the service worker of the app.
+type Notification struct {
+	Title string
+	Body  string
+	Icon  string
+
+	//Notifications with the same tag replace each other.
+	Tag string
+
+	//Page is navigated to when the notification is clicked.
+	Page page.Page
+}
+
+//Sender sends Web Push messages.
+type Sender struct {
+	//Subject is a contact for the app, either a mailto: or https: URL.
+	Subject string
+
+	//TTL is how long the push service should keep the message if the client is offline.
+	TTL time.Duration
+
+	//Urgency is one of "very-low", "low", "normal" or "high".
+	Urgency string
+
+	Client *http.Client
+}
+
+//DefaultSender is the Sender used by Send.
+var DefaultSender = Sender{
+	TTL: 24 * time.Hour,
+}
+
+//Send sends the notification to the subscription with the DefaultSender.
+func Send(s Subscription, n Notification) error {
+	return DefaultSender.Send(s, n)
+}
+
+//Send sends the notification to the subscription.
+func (sender Sender) Send(s Subscription, n Notification) error {
+	var message = struct {
+		Title string `json:"title"`
+		Body  string `json:"body,omitempty"`
+		Icon  string `json:"icon,omitempty"`
+		Tag   string `json:"tag,omitempty"`
+		Page  string `json:"page,omitempty"`
+	}{
+		Title: n.Title,
+		Body:  n.Body,
+		Icon:  n.Icon,
+		Tag:   n.Tag,
+	}
+	if n.Page != nil {
+		message.Page = page.ID(n.Page)
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("push: could not encode notification: %w", err)
+	}
+
+	return sender.SendData(s, payload)
+}
+
+//SendData sends an encrypted payload (RFC 8291) to the subscription.
+func (sender Sender) SendData(s Subscription, payload []byte) error {
+	body, err := encrypt(s, payload, rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := vapid(s.Endpoint, sender.Subject, Key())
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest("POST", s.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("push: invalid endpoint: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/octet-stream")
+	request.Header.Set("Content-Encoding", "aes128gcm")
+	request.Header.Set("TTL", strconv.Itoa(int(sender.TTL/time.Second)))
+	request.Header.Set("Authorization", authorization)
+	if sender.Urgency != "" {
+		request.Header.Set("Urgency", sender.Urgency)
+	}
+
+	var client = sender.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("push: could not send: %w", err)
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusNotFound, response.StatusCode == http.StatusGone:
+		return ErrExpired
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		reason, _ := ioutil.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("push: %v: %s", response.Status, reason)
+	}
+
+	return nil
+}
+
+func hmacSHA256(key []byte, data ...[]byte) []byte {
+	var mac = hmac.New(sha256.New, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+//encrypt encrypts the payload for the subscription with the aes128gcm content-encoding.
+func encrypt(s Subscription, payload []byte, random io.Reader) ([]byte, error) {
+	var curve = elliptic.P256()
+
+	uaPublic, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s.Keys.P256dh, "="))
+	if err != nil {
+		return nil, fmt.Errorf("push: invalid p256dh key: %w", err)
+	}
+	authSecret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s.Keys.Auth, "="))
+	if err != nil {
+		return nil, fmt.Errorf("push: invalid auth secret: %w", err)
+	}
+
+	x, y := elliptic.Unmarshal(curve, uaPublic)
+	if x == nil {
+		return nil, errors.New("push: invalid p256dh key")
+	}
+
+	if len(payload) > recordSize-16-1-86 {
+		return nil, errors.New("push: payload is too large")
+	}
+
+	//Ephemeral application server key pair, the private key is read directly from random.
+	var d = make([]byte, 32)
+	for {
+		if _, err := io.ReadFull(random, d); err != nil {
+			return nil, err
+		}
+		if k := new(big.Int).SetBytes(d); k.Sign() > 0 && k.Cmp(curve.Params().N) < 0 {
+			break
+		}
+	}
+	var asPrivate = newPrivateKey(d)
+	var asPublic = elliptic.Marshal(curve, asPrivate.X, asPrivate.Y)
+
+	var salt = make([]byte, 16)
+	if _, err := io.ReadFull(random, salt); err != nil {
+		return nil, err
+	}
+
+	sx, _ := curve.ScalarMult(x, y, asPrivate.D.Bytes())
+	var secret = make([]byte, 32)
+	fill(sx, secret)
+
+	//RFC 8291 section 3.4
+	var prkKey = hmacSHA256(authSecret, secret)
+	var ikm = hmacSHA256(prkKey, []byte("WebPush: info\x00"), uaPublic, asPublic, []byte{1})
+	var prk = hmacSHA256(salt, ikm)
+	var cek = hmacSHA256(prk, []byte("Content-Encoding: aes128gcm\x00\x01"))[:16]
+	var nonce = hmacSHA256(prk, []byte("Content-Encoding: nonce\x00\x01"))[:12]
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	//The padding delimiter of the last record.
+	var plaintext = append(append([]byte(nil), payload...), 2)
+
+	var header = make([]byte, 16+4+1, 16+4+1+len(asPublic))
+	copy(header, salt)
+	binary.BigEndian.PutUint32(header[16:], recordSize)
+	header[20] = byte(len(asPublic))
+	header = append(header, asPublic...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+//vapid returns the VAPID (RFC 8292) Authorization header for the given endpoint.
+func vapid(endpoint, subject string, private *ecdsa.PrivateKey) (string, error) {
+	location, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("push: invalid endpoint: %w", err)
+	}
+
+	var claims = map[string]interface{}{
+		"aud": location.Scheme + "://" + location.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+	}
+	if subject != "" {
+		claims["sub"] = subject
+	}
+
+	encodedClaims, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	var unsigned = base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`)) +
+		"." + base64.RawURLEncoding.EncodeToString(encodedClaims)
+
+	var digest = sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, private, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	var signature = make([]byte, 64)
+	fill(r, signature[:32])
+	fill(s, signature[32:])
+
+	var public = base64.RawURLEncoding.EncodeToString(elliptic.Marshal(private.Curve, private.X, private.Y))
+
+	return "vapid t=" + unsigned + "." + base64.RawURLEncoding.EncodeToString(signature) + ", k=" + public, nil
+}
+
+//fill writes n into b as a fixed size big-endian number.
+func fill(n *big.Int, b []byte) {
+	var raw = n.Bytes()
+	copy(b[len(b)-len(raw):], raw)
+}
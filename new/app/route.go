@@ -0,0 +1,89 @@
+package app
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"qlova.org/seed"
+	"qlova.org/seed/new/page"
+	"qlova.org/seed/new/text"
+	"qlova.org/seed/set/center"
+)
+
+//alias routes the url paths that match a pattern to a page.
+type alias struct {
+	pattern string
+	page    page.Page
+}
+
+//Route routes the url paths that match the pattern to the page, the segments of the pattern in braces
+//are passed to the page as the arguments with the same url tag, ie.
+//	app.Route("/u/{1}", ProfilePage{})
+//routes "/u/bob" to the ProfilePage with its `url:"1"` argument set to "bob".
+//A trailing "*" matches the rest of the path.
+func Route(pattern string, p page.Page) seed.Option {
+	return seed.Mutate(func(a *app) {
+		a.aliases = append(a.aliases, alias{pattern, p})
+	})
+}
+
+//SetNotFoundPage sets the page that is shown, with a 404 status, for url paths that are not routed to any page.
+func SetNotFoundPage(p page.Page) seed.Option {
+	return seed.Mutate(func(a *app) {
+		a.notFoundPage = p
+	})
+}
+
+//notFound is the default not found page.
+type notFound struct{}
+
+func (notFound) Page(r page.Router) seed.Seed {
+	return page.New(
+		page.SetTitle("Page not found"),
+
+		center.This(
+			text.New(text.SetString("404 page not found")),
+		),
+	)
+}
+
+//harvested returns true if the page has been harvested into c.
+func harvested(c seed.Seed, p page.Page) bool {
+	for _, route := range page.Routes(c) {
+		if reflect.TypeOf(route.Page) == reflect.TypeOf(p) {
+			return true
+		}
+	}
+	return false
+}
+
+//initial returns the route that the client starts on for the url path and raw query, so that deep links are shown
+//directly, without searching the pages on the client. Returns false if the client can find the page by its path.
+func (p prerendered) initial(path, query string, missing bool) ([]byte, bool) {
+	if !missing && path == p.Path && query == "" {
+		return nil, false
+	}
+
+	var route = struct {
+		Page    string                 `json:"page"`
+		Args    map[string]interface{} `json:"args"`
+		URL     string                 `json:"url"`
+		Views   string                 `json:"views,omitempty"`
+		Missing bool                   `json:"missing,omitempty"`
+	}{
+		Page:    page.ID(p.Page),
+		Args:    map[string]interface{}{},
+		Missing: missing,
+	}
+
+	if !missing {
+		route.Args, route.URL, route.Views = p.Parse(path, query)
+	}
+
+	encoded, err := json.Marshal(route)
+	if err != nil {
+		return nil, false
+	}
+
+	return encoded, true
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"qlova.org/seed"
+	"qlova.org/seed/client"
+)
+
+//Security configures the security headers that the app sends with each response.
+type Security struct {
+	//CSP enables a strict Content Security Policy. Inline scripts and styles of the app are allowed by
+	//their hashes (computed when the app is built) and remote procedure calls return data instead of javascript,
+	//so Go functions called by the client (see client.Go) must not return a client.Script, these calls fail.
+	//Apps that use wasm cannot have a strict policy.
+	CSP bool
+
+	//Policy adds sources to the directives of the policy, ie.
+	//	map[string][]string{"img-src": {"https://images.example.com"}}
+	Policy map[string][]string
+
+	//HSTS is the max-age of the Strict-Transport-Security header, it is not sent when zero.
+	HSTS                  time.Duration
+	HSTSIncludeSubdomains bool
+
+	//NoSniff sends X-Content-Type-Options: nosniff.
+	NoSniff bool
+
+	//ReferrerPolicy is the Referrer-Policy header, ie. "strict-origin-when-cross-origin".
+	ReferrerPolicy string
+
+	//FrameAncestors are the sources that may embed the app in a frame, ie. "'none'" or "'self'".
+	//Sent as the frame-ancestors directive, even when CSP is false.
+	FrameAncestors []string
+}
+
+//StrictSecurity is a recommended Security for apps served over HTTPS.
+var StrictSecurity = Security{
+	CSP:            true,
+	HSTS:           365 * 24 * time.Hour,
+	NoSniff:        true,
+	ReferrerPolicy: "strict-origin-when-cross-origin",
+	FrameAncestors: []string{"'none'"},
+}
+
+//SetSecurity sets the security headers of the app, ie.
+//	app.SetSecurity(app.StrictSecurity)
+func SetSecurity(security Security) seed.Option {
+	return seed.Mutate(func(a *app) {
+		a.security = security
+	})
+}
+
+//inline matches the inline scripts and styles of a rendered document.
+var inline = regexp.MustCompile(`(?is)<(script|style)((?:\s[^>]*)?)>(.*?)</(?:script|style)>`)
+
+//hashes returns the CSP hashes of the inline scripts and styles in the document.
+func hashes(document []byte) (scripts, styles []string) {
+	for _, match := range inline.FindAllSubmatch(document, -1) {
+		if strings.Contains(strings.ToLower(string(match[2])), "src=") {
+			continue
+		}
+
+		var sum = sha256.Sum256(match[3])
+		var hash = "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+
+		if strings.ToLower(string(match[1])) == "script" {
+			scripts = append(scripts, hash)
+		} else {
+			styles = append(styles, hash)
+		}
+	}
+	return
+}
+
+//policy returns the Content-Security-Policy of the rendered document, local
+//policies allow the development socket.
+func (security Security) policy(document []byte, local bool) string {
+	var directives = make(map[string][]string)
+
+	if security.CSP {
+		scripts, styles := hashes(document)
+
+		directives["default-src"] = []string{"'self'"}
+		directives["script-src"] = append([]string{"'self'"}, scripts...)
+		directives["style-src"] = append([]string{"'self'"}, styles...)
+		//Seeds are styled with style attributes.
+		directives["style-src-attr"] = []string{"'unsafe-inline'"}
+		directives["img-src"] = []string{"'self'", "data:", "blob:"}
+		directives["connect-src"] = []string{"'self'"}
+		directives["object-src"] = []string{"'none'"}
+		directives["base-uri"] = []string{"'self'"}
+		directives["form-action"] = []string{"'self'"}
+
+		if local {
+			directives["script-src"] = append(directives["script-src"], "'unsafe-eval'")
+			directives["connect-src"] = append(directives["connect-src"], "ws:", "wss:")
+		}
+
+		for directive, sources := range security.Policy {
+			directives[directive] = append(directives[directive], sources...)
+		}
+	}
+
+	if len(security.FrameAncestors) > 0 {
+		directives["frame-ancestors"] = security.FrameAncestors
+	}
+
+	//Deterministic order.
+	var keys = make([]string, 0, len(directives))
+	for directive := range directives {
+		keys = append(keys, directive)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, directive := range keys {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(directive)
+		for _, source := range directives[directive] {
+			b.WriteString(" " + source)
+		}
+	}
+	return b.String()
+}
+
+//headers returns a handler that sets the security headers before calling next.
+func (security Security) headers(document []byte, next http.Handler) http.Handler {
+	var policy, local = security.policy(document, false), security.policy(document, true)
+
+	var hsts string
+	if security.HSTS > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(security.HSTS/time.Second))
+		if security.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var header = w.Header()
+
+		if policy != "" {
+			if isLocal(r) {
+				header.Set("Content-Security-Policy", local)
+			} else {
+				header.Set("Content-Security-Policy", policy)
+			}
+		}
+		if hsts != "" {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+		if security.NoSniff {
+			header.Set("X-Content-Type-Options", "nosniff")
+		}
+		if security.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", security.ReferrerPolicy)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func init() {
+	client.RegisterRenderer(func(c seed.Seed) []byte {
+		var app app
+		c.Load(&app)
+
+		if app.security.CSP {
+			return []byte(`seed.csp = true;`)
+		}
+		return nil
+	})
+}
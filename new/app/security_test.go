@@ -0,0 +1,52 @@
+package app
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"qlova.org/seed"
+	"qlova.org/seed/client"
+	"qlova.org/seed/new/page"
+	"qlova.org/seed/new/text"
+)
+
+type describedPage struct{}
+
+func (describedPage) Page(page.Router) seed.Seed {
+	return page.New(text.New(text.Set("described"), client.OnClick(client.NewScript())))
+}
+
+func (describedPage) Describe(client.Request) page.Meta {
+	return page.Meta{Title: "Described", Description: "A described page."}
+}
+
+func TestDescribedPolicy(t *testing.T) {
+	var handler = New("Described",
+		SetSecurity(Security{CSP: true}),
+		page.AddPages(describedPage{}),
+		page.Set(describedPage{}),
+	).Handler()
+
+	var w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	var response = w.Result()
+	document, _ := ioutil.ReadAll(response.Body)
+	if !strings.Contains(string(document), "<title>Described</title>") {
+		t.Fatal("expected the page to be described")
+	}
+
+	var policy = response.Header.Get("Content-Security-Policy")
+
+	scripts, styles := hashes(document)
+	if len(scripts) == 0 {
+		t.Fatal("expected the document to have inline scripts")
+	}
+	for _, hash := range append(scripts, styles...) {
+		if !strings.Contains(policy, hash) {
+			t.Errorf("the inline script or style %v of the described page is not allowed by the policy", hash)
+		}
+	}
+}
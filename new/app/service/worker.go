@@ -2,10 +2,49 @@ package service
 
 import (
 	"bytes"
+	"encoding/json"
+	"path"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
+//Strategy is a service worker caching strategy.
+type Strategy string
+
+//Caching strategies.
+const (
+	//CacheFirst serves from the cache and only goes to the network when nothing is cached.
+	CacheFirst Strategy = "cacheFirst"
+
+	//NetworkFirst goes to the network and only serves from the cache when the network fails.
+	NetworkFirst Strategy = "networkFirst"
+
+	//StaleWhileRevalidate serves from the cache and updates the cache from the network in the background.
+	StaleWhileRevalidate Strategy = "staleWhileRevalidate"
+
+	//NetworkOnly never caches, requests are always sent to the network.
+	NetworkOnly Strategy = "networkOnly"
+)
+
+//Route is a caching rule for requests whose path starts with Prefix.
+type Route struct {
+	Prefix string
+
+	//Extensions restricts the route to paths with one of these file extensions, ie. ".png"
+	Extensions []string
+
+	Strategy Strategy
+
+	//MaxEntries is the maximum number of responses that are cached for this route,
+	//the oldest responses are removed first. Zero means no limit.
+	MaxEntries int
+
+	//MaxAge is how long a cached response remains valid. Zero means forever.
+	MaxAge time.Duration
+}
+
 //NewWorker returns a new service worker.
 func NewWorker() *Worker {
 	return &Worker{
@@ -17,8 +56,29 @@ func NewWorker() *Worker {
 type Worker struct {
 	Version string
 	Assets  map[string]bool
+
+	//Routes are the caching rules of the worker, when multiple routes match a request,
+	//the route with the longest prefix is used. Requests that don't match a route are network-first,
+	//cross-origin requests are never handled by the worker.
+	Routes []Route
+
+	//Offline is the path that navigations are redirected to when the network is unavailable
+	//and the page has not been cached, it is precached on install.
+	Offline string
+
+	//Fresh is true when navigations should prefer the network over the precached document,
+	//because the server injects per-request state into the document.
+	Fresh bool
 }
 
+//Handle adds the given caching rule to the worker.
+func (worker *Worker) Handle(route Route) {
+	worker.Routes = append(worker.Routes, route)
+}
+
+//never is the route that is never cached, remote procedure calls must always reach the server.
+var never = Route{Prefix: "/go/", Strategy: NetworkOnly}
+
 func (worker Worker) renderMap(b *bytes.Buffer, mapping map[string]bool) {
 	//Deterministic render
 	keys := make([]string, 0, len(mapping))
@@ -43,6 +103,53 @@ func (worker Worker) renderMap(b *bytes.Buffer, mapping map[string]bool) {
 	}
 }
 
+//renderRoutes renders the routes of the worker as a JSON array, ordered from most to least specific.
+func (worker Worker) renderRoutes(b *bytes.Buffer) {
+	type route struct {
+		Prefix     string   `json:"prefix"`
+		Extensions []string `json:"extensions,omitempty"`
+		Strategy   Strategy `json:"strategy"`
+		Cache      string   `json:"cache"`
+		MaxEntries int      `json:"maxEntries,omitempty"`
+		MaxAge     int64    `json:"maxAge,omitempty"`
+	}
+
+	var routes = append([]Route{never}, worker.Routes...)
+
+	var rendered = make([]route, len(routes))
+	for i, r := range routes {
+		var extensions = make([]string, len(r.Extensions))
+		for j, ext := range r.Extensions {
+			extensions[j] = "." + strings.TrimPrefix(ext, ".")
+		}
+
+		var strategy = r.Strategy
+		if strategy == "" {
+			strategy = CacheFirst
+		}
+
+		rendered[i] = route{
+			Prefix:     path.Clean("/" + r.Prefix),
+			Extensions: extensions,
+			Strategy:   strategy,
+			Cache:      "route-" + strconv.Itoa(i),
+			MaxEntries: r.MaxEntries,
+			MaxAge:     int64(r.MaxAge / time.Millisecond),
+		}
+		if strings.HasSuffix(r.Prefix, "/") && rendered[i].Prefix != "/" {
+			rendered[i].Prefix += "/"
+		}
+	}
+
+	//The never-cached route always takes precedence.
+	sort.SliceStable(rendered[1:], func(i, j int) bool {
+		return len(rendered[1+i].Prefix) > len(rendered[1+j].Prefix)
+	})
+
+	encoded, _ := json.Marshal(rendered)
+	b.Write(encoded)
+}
+
 //Render the service worker to JS.
 func (worker Worker) Render() []byte {
 	var b bytes.Buffer
@@ -51,51 +158,209 @@ func (worker Worker) Render() []byte {
 	b.WriteString(worker.Version)
 	b.WriteString(`";`)
 
-	b.WriteString(`self.addEventListener('install', function(event) {
+	b.WriteString(`
+const precache = "assets-" + version;
+const offline = `)
+	b.WriteString(strconv.Quote(worker.Offline))
+	b.WriteString(`;
+const fresh = `)
+	b.WriteString(strconv.FormatBool(worker.Fresh))
+	b.WriteString(`;
+const routes = `)
+	worker.renderRoutes(&b)
+	b.WriteString(`;
+const fallback = {prefix: "/", strategy: "networkFirst", cache: "dynamic-" + version};
+
+self.addEventListener('install', function(event) {
 		self.skipWaiting();
-		caches.delete("dynamic");
   event.waitUntil(
-    caches.open("assets").then(function(cache) {
-      return cache.addAll(
-        ["/", `)
+    caches.open(precache).then(function(cache) {
+      //The document is cached without any per-request state.
+      let assets = [new Request("/", {headers: {"Seed-Shell": "true"}}), `)
 
 	worker.renderMap(&b, worker.Assets)
 
-	b.WriteString(`]
-      );
+	b.WriteString(`];
+      if (offline) assets.push(offline);
+      return cache.addAll(assets);
     }).catch(function(e) {
 		console.log("Couldn't install because: ", e);
 	})
   );
 });
 
-self.addEventListener('fetch', event => event.respondWith(cacheThenNetwork(event)));
+//Remove the caches of previous versions and of routes that no longer exist.
+self.addEventListener('activate', function(event) {
+	let current = [precache, fallback.cache].concat(routes.map(route => route.cache));
+	event.waitUntil(
+		caches.keys().then(keys => Promise.all(
+			keys.filter(key => !current.includes(key)).map(key => caches.delete(key))
+		))
+	);
+});
 
-async function cacheThenNetwork(event) {
+self.addEventListener('fetch', function(event) {
 	let request = event.request;
 
-	const assets = await caches.open("assets");
+	//Only GET requests are cached.
+	if (request.method != "GET") return;
+
+	let route = match(new URL(request.url));
+	if (!route || route.strategy == "networkOnly") return;
 
-	//Try load a cached asset first.
+	event.respondWith(handle(event, route));
+});
+
+//Replay calls that were queued by the client whilst it was offline.
+self.addEventListener('sync', function(event) {
+	if (event.tag == "seed.queue") event.waitUntil(replay());
+});
+
+function queue(mode, f) {
+	return new Promise(function(resolve, reject) {
+		let open = indexedDB.open("seed", 1);
+		open.onupgradeneeded = function() {
+			open.result.createObjectStore("queue", {keyPath: "key"});
+		};
+		open.onerror = function() { reject(open.error); };
+		open.onsuccess = function() {
+			let tx = open.result.transaction("queue", mode);
+			let request = f(tx.objectStore("queue"));
+			tx.oncomplete = function() { resolve(request.result); };
+			tx.onerror = function() { reject(tx.error); };
+		};
+	});
+}
+
+//replay sends each queued call, the sync is retried by the browser if this fails.
+async function replay() {
+	const items = await queue("readonly", store => store.getAll());
+	for (let item of items) {
+		let body = new FormData();
+		for (let [key, value] of item.entries) body.append(key, value);
+
+		const response = await fetch(item.url, {
+			method: "POST",
+			body: body,
+			headers: item.headers,
+		});
+		if (response.status >= 500) throw new Error("could not replay " + item.url + ": " + response.status);
+
+		const text = await response.text();
+		const type = response.headers.get("Content-Type");
+		await queue("readwrite", store => store.delete(item.key));
+
+		for (const client of await self.clients.matchAll()) {
+			client.postMessage({type: "seed.queue", key: item.key, response: {body: text, type: type}});
+		}
+	}
+}
+
+//Show push notifications sent by the app, see the push package.
+self.addEventListener('push', function(event) {
+	let message = {};
+	if (event.data) {
+		try {
+			message = event.data.json();
+		} catch(e) {
+			message = {title: event.data.text()};
+		}
+	}
+
+	event.waitUntil(self.registration.showNotification(message.title || "", {
+		body: message.body,
+		icon: message.icon,
+		tag: message.tag,
+		data: {page: message.page},
+	}));
+});
+
+//Focus the app (or open it) and go to the page of the notification.
+self.addEventListener('notificationclick', function(event) {
+	event.notification.close();
+
+	let page = event.notification.data && event.notification.data.page;
+
+	event.waitUntil((async function() {
+		const windows = await self.clients.matchAll({type: "window", includeUncontrolled: true});
+
+		let client = windows[0];
+		if (client) {
+			client = await client.focus();
+		} else {
+			client = await self.clients.openWindow("/");
+		}
+
+		if (client && page) client.postMessage({type: "seed.push", page: page});
+	})());
+});
+
+//match returns the route for the given url, or null if the url is cross-origin.
+function match(url) {
+	if (url.origin != location.origin) return null;
+
+	for (let route of routes) {
+		if (!url.pathname.startsWith(route.prefix)) continue;
+		if (route.extensions && !route.extensions.some(ext => url.pathname.endsWith(ext))) continue;
+		return route;
+	}
+
+	return fallback;
+}
+
+async function handle(event, route) {
+	let request = event.request;
+
+	const assets = await caches.open(precache);
+
+	//Navigations are never served cache-first, so that documents always match the deployed assets.
+	//The precached document is the fallback. Fresh navigations carry per-request state, so they aren't cached.
+	if (request.mode == "navigate") {
+		try {
+			return fresh ? await fetch(request) : await network(route, request);
+		} catch (e) {
+			const CachedResponse = (fresh ? null : await cached(route, request)) || await assets.match(request) || await assets.match("/");
+			if (CachedResponse) return CachedResponse;
+			if (offline) return Response.redirect(offline);
+
+			return new Response("404 not found", {
+				status: 404,
+			})
+		}
+	}
+
+	//Precached assets are always served from the cache.
 	const CachedAsset = await assets.match(request);
 	if (CachedAsset) return CachedAsset;
 
-	//Get the request from the network.
 	try {
-		let clone = request.clone();
-		clone.url = request.url+"?="+Math.random();
-		const NetworkReponse = await fetch(clone, {cache: "no-store"});
-		if (request.method == "GET" && NetworkReponse.status == 200) {
-			const dynamic = await caches.open("dynamic");
-			dynamic.put(request, NetworkReponse.clone());
-		}
-		return NetworkReponse;
+		switch (route.strategy) {
+		case "networkFirst": {
+			try {
+				return await network(route, request);
+			} catch (e) {
+				const CachedResponse = await cached(route, request);
+				if (CachedResponse) return CachedResponse;
+				throw e;
+			}
+		}
+		case "staleWhileRevalidate": {
+			const update = network(route, request);
+			event.waitUntil(update.catch(function() {}));
+
+			const StaleResponse = await cached(route, request);
+			if (StaleResponse) return StaleResponse;
+			return await update;
+		}
+		default: {
+			const CachedResponse = await cached(route, request);
+			if (CachedResponse) return CachedResponse;
+			return await network(route, request);
+		}
+		}
 	} catch (e) {
-		//Try the dynamic cache.
-		if (request.method == "GET") {
-			const dynamic = await caches.open("dynamic");
-			const CachedDynamic = await dynamic.match(request);
-			if (CachedDynamic) return CachedDynamic;
+		if (request.mode == "navigate" && offline) {
+			return Response.redirect(offline);
 		}
 
 		return new Response("404 not found", {
@@ -103,6 +368,50 @@ async function cacheThenNetwork(event) {
 		})
 	}
 }
+
+//cached returns the cached response of the request, if it has not expired.
+async function cached(route, request) {
+	const cache = await caches.open(route.cache);
+	const response = await cache.match(request);
+	if (!response) return null;
+
+	if (route.maxAge && Date.now() - (+response.headers.get("x-seed-cached")) > route.maxAge) {
+		await cache.delete(request);
+		return null;
+	}
+
+	return response;
+}
+
+//network fetches the request and caches the response.
+async function network(route, request) {
+	const response = await fetch(request);
+	if (response.status == 200 && (response.type == "basic" || response.type == "cors")) {
+		await store(route, request, response.clone());
+	}
+	return response;
+}
+
+//store caches the response, evicting the oldest responses beyond the route's limit.
+async function store(route, request, response) {
+	const cache = await caches.open(route.cache);
+
+	let headers = new Headers(response.headers);
+	headers.set("x-seed-cached", Date.now());
+
+	await cache.put(request, new Response(await response.blob(), {
+		status: response.status,
+		statusText: response.statusText,
+		headers: headers,
+	}));
+
+	if (route.maxEntries) {
+		let keys = await cache.keys();
+		for (let i = 0; i < keys.length - route.maxEntries; i++) {
+			await cache.delete(keys[i]);
+		}
+	}
+}
 `)
 
 	return b.Bytes()
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"qlova.org/seed"
+	"qlova.org/seed/client"
+	"qlova.org/seed/new/app/manifest"
+	"qlova.org/seed/new/page"
+)
+
+//maxShareMemory is the amount of shared files that are kept in memory, the rest are stored in temporary files.
+const maxShareMemory = 32 << 20
+
+//Share is content shared to the app from the OS share sheet, or files opened with the app.
+type Share struct {
+	Title, Text, URL string
+
+	Files []client.Stream
+}
+
+//share routes the shares posted to action, to a Go handler and then lands on a page.
+type share struct {
+	action string
+
+	landing page.Page
+	handler func(client.Request, Share) error
+
+	//path of the landing page.
+	path string
+}
+
+//ShareTarget registers the installed app as a target of the OS share sheet, accepting files with the given
+//mime types, ie. "image/*". Shares are passed to the handler, after which the app lands on the given page.
+//The title, text, url and names of the shared files are passed to the page as the arguments
+//"title", "text", "url" and "files" (comma separated), ie.
+//	type SharedPage struct {
+//		Title client.String `url:"title"`
+//	}
+//If the handler returns an error, it is passed to the page as the argument "error".
+//A client.Redirect error redirects to the given URL instead. An app can only have one share target.
+func ShareTarget(landing page.Page, handler func(client.Request, Share) error, accept ...string) seed.Option {
+	return seed.Mutate(func(a *app) {
+		for _, existing := range a.shares {
+			if existing.action == "/share" {
+				panic("app.ShareTarget: the app already has a share target")
+			}
+		}
+
+		var target = manifest.ShareTarget{
+			Action:  "/share",
+			Method:  "POST",
+			Enctype: "multipart/form-data",
+			Params: manifest.ShareParams{
+				Title: "title",
+				Text:  "text",
+				URL:   "url",
+			},
+		}
+		if len(accept) > 0 {
+			target.Params.Files = []manifest.ShareFile{{Name: "files", Accept: accept}}
+		}
+
+		a.manifest.SetShareTarget(target)
+		a.shares = append(a.shares, share{
+			action:  target.Action,
+			landing: landing,
+			handler: handler,
+		})
+	})
+}
+
+//HandleFiles registers the installed app as a handler of the given file types, so that they
+//can be opened with the app, ie.
+//	app.HandleFiles(EditorPage{}, open, map[string][]string{"text/markdown": {".md"}})
+//Opened files are passed to the handler and then the app lands on the given page, as with ShareTarget.
+//Each landing page can only handle files once, so all of its file types must be passed together.
+func HandleFiles(landing page.Page, handler func(client.Request, Share) error, accept map[string][]string) seed.Option {
+	return seed.Mutate(func(a *app) {
+		var action = "/open/" + page.ID(landing)[1:]
+
+		for _, existing := range a.shares {
+			if existing.action == action {
+				panic("app.HandleFiles: " + page.ID(landing) + " already handles files, pass all of its file types to one HandleFiles")
+			}
+		}
+
+		a.manifest.AddFileHandler(manifest.FileHandler{
+			Action: action,
+			Accept: accept,
+		})
+		a.shares = append(a.shares, share{
+			action:  action,
+			landing: landing,
+			handler: handler,
+		})
+	})
+}
+
+//sameSite returns true if the request was sent by the app itself, or by the OS on behalf of the user,
+//rather than by a form on another site.
+func sameSite(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none":
+		return true
+	case "":
+		//Browsers that don't send Sec-Fetch-Site send the Origin of cross-site posts.
+		var origin = r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		location, err := url.Parse(origin)
+		return err == nil && location.Host == r.Host
+	default:
+		return false
+	}
+}
+
+//ServeHTTP handles a posted share and redirects to the landing page.
+func (s share) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var args = make(url.Values)
+	var shared Share
+
+	if !sameSite(r) {
+		http.Error(w, "cross-site shares are not allowed", http.StatusForbidden)
+		return
+	}
+
+	if err := r.ParseMultipartForm(maxShareMemory); err != nil && err != http.ErrNotMultipart {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	shared.Title = r.FormValue("title")
+	shared.Text = r.FormValue("text")
+	shared.URL = r.FormValue("url")
+
+	var names []string
+	if r.MultipartForm != nil {
+		for _, header := range r.MultipartForm.File["files"] {
+			stream, err := client.NewStream(header)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			defer stream.Close()
+
+			shared.Files = append(shared.Files, stream)
+			names = append(names, stream.Name())
+		}
+		defer r.MultipartForm.RemoveAll()
+	}
+
+	if err := s.handler(client.NewRequest(w, r), shared); err != nil {
+		if redirect, ok := err.(client.Redirect); ok {
+			http.Redirect(w, r, string(redirect), http.StatusSeeOther)
+			return
+		}
+
+		log.Println(err)
+		args.Set("error", err.Error())
+	}
+
+	for key, value := range map[string]string{
+		"title": shared.Title,
+		"text":  shared.Text,
+		"url":   shared.URL,
+		"files": strings.Join(names, ","),
+	} {
+		if value != "" {
+			args.Set(key, value)
+		}
+	}
+
+	var location = s.path
+	if len(args) > 0 {
+		location += "?" + args.Encode()
+	}
+
+	http.Redirect(w, r, location, http.StatusSeeOther)
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"sync/atomic"
 
 	"github.com/gorilla/websocket"
 )
@@ -32,6 +33,9 @@ func socket(w http.ResponseWriter, r *http.Request) {
 	}
 	defer c.Close()
 
+	atomic.AddInt64(&sockets, 1)
+	defer atomic.AddInt64(&sockets, -1)
+
 	localSockets[r.RemoteAddr] = c
 
 	reloading = false
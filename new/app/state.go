@@ -0,0 +1,136 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"qlova.org/seed"
+	"qlova.org/seed/assets/inbed"
+	"qlova.org/seed/client"
+	"qlova.org/seed/client/clientside"
+	"qlova.org/seed/new/feed"
+)
+
+//State is the initial state of the app for a single request, it is injected
+//into the served document so that the client doesn't need to ask for it.
+type State struct {
+	Vars  [][3]json.RawMessage       `json:"vars,omitempty"`
+	Feeds map[string]json.RawMessage `json:"feeds,omitempty"`
+}
+
+//Set sets the initial value of the variable, the value is encoded as JSON.
+func (s *State) Set(variable clientside.Variable, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("could not encode state: %w", err)
+	}
+
+	address, memory := variable.Variable()
+	encodedAddress, _ := json.Marshal(string(address))
+	encodedMemory, _ := json.Marshal(string(memory))
+
+	s.Vars = append(s.Vars, [3]json.RawMessage{encodedAddress, encodedMemory, encoded})
+	return nil
+}
+
+//SetFeed sets the initial data of the feed, the first refresh of the feed uses this
+//data instead of calling the server. The data is encoded as JSON.
+func (s *State) SetFeed(f *feed.Feed, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("could not encode feed state: %w", err)
+	}
+
+	if s.Feeds == nil {
+		s.Feeds = make(map[string]json.RawMessage)
+	}
+	s.Feeds[f.ID()] = encoded
+	return nil
+}
+
+//InitialState sets a hook that is called for each request of the document, to set the initial state of the client.
+//When the hook doesn't set any state (ie. for anonymous users) the static, cacheable document is served.
+func InitialState(hook func(client.Request, *State) error) seed.Option {
+	return seed.Mutate(func(a *app) {
+		a.state = hook
+	})
+}
+
+//shellHeader is sent by the service worker when it caches the document, which must not have any state.
+const shellHeader = "Seed-Shell"
+
+//stateful returns the document with the state returned by the hook. Returns false
+//if the document has no state for the request, and the static document should be served instead.
+func (app app) stateful(w http.ResponseWriter, r *http.Request, document []byte) ([]byte, bool) {
+	if app.state == nil || r.Header.Get(shellHeader) != "" {
+		return nil, false
+	}
+
+	var state State
+	if err := app.state(client.NewRequest(w, r), &state); err != nil {
+		log.Println(err)
+		return nil, false
+	}
+
+	if len(state.Vars) == 0 && len(state.Feeds) == 0 {
+		return nil, false
+	}
+
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		log.Println(err)
+		return nil, false
+	}
+
+	return embed(document, "seed.state", encoded), true
+}
+
+//embed returns the document with the JSON data embedded as a script with the given id.
+//The JSON must be encoded by json.Marshal, which escapes '<', '>' and '&' so that the data cannot close the script.
+func embed(document []byte, id string, data []byte) []byte {
+	var insert = bytes.LastIndex(document, []byte("</body>"))
+	if insert < 0 {
+		insert = len(document)
+	}
+
+	var b bytes.Buffer
+	b.Write(document[:insert])
+	b.WriteString(`<script type="application/json" id="` + id + `">`)
+	b.Write(data)
+	b.WriteString(`</script>`)
+	b.Write(document[insert:])
+
+	return b.Bytes()
+}
+
+//dynamic serves a document that was generated for the request, with the given status and Cache-Control header.
+func dynamic(w http.ResponseWriter, r *http.Request, document []byte, status int, control string) {
+	var header = w.Header()
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	header.Set("Cache-Control", control)
+	header.Set("Vary", "Accept-Encoding, Cookie")
+
+	var gzipped = r.Method != http.MethodHead && inbed.AcceptsEncoding(r, "gzip")
+	if gzipped {
+		header.Set("Content-Encoding", "gzip")
+	}
+
+	w.WriteHeader(status)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if gzipped {
+		gw := gzip.NewWriter(w)
+		gw.Write(document)
+		gw.Close()
+		return
+	}
+
+	w.Write(document)
+}
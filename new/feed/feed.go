@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"reflect"
 	"strings"
 
 	"qlova.org/mirror"
@@ -25,6 +26,11 @@ func Templates(root seed.Seed) []seed.Seed {
 
 	var result = data.templates
 
+	//nested feeds are within the templates of their parent.
+	for _, template := range data.templates {
+		result = append(result, Templates(template)...)
+	}
+
 	for _, child := range root.Children() {
 		if slice := Templates(child); slice != nil {
 			result = append(result, slice...)
@@ -63,7 +69,22 @@ type Feed struct {
 
 	Empty client.Bool
 
+	//loading and exhausted are the state of a paginated feed.
+	loading, exhausted *clientside.Bool
+
+	//Loading is true whilst a page of a paginated feed is loading.
+	Loading client.Bool
+
+	//Exhausted is true once the last page of a paginated feed has been loaded.
+	Exhausted client.Bool
+
 	mirror mirror.Type
+
+	//structure is the type passed to Into.
+	structure reflect.Type
+
+	//into is the pointer passed to Into, fields are located by their address within it.
+	into reflect.Value
 }
 
 //GetBool implements js.AnyBool
@@ -81,6 +102,11 @@ func (f *Feed) Components() []client.Value {
 	return []client.Value{f.boolean}
 }
 
+//ID returns the client ID of the feed, this identifies the feed in the client.
+func (f *Feed) ID() string {
+	return client.ID(f.feed)
+}
+
 //Refresh refreshes the feed.
 func (f *Feed) Refresh() client.Script {
 	return html.Element(f.feed).Run("onrefresh")
@@ -110,6 +136,9 @@ func With(food Food, options ...seed.Option) *Feed {
 
 		boolean: new(clientside.Bool),
 
+		loading:   new(clientside.Bool),
+		exhausted: new(clientside.Bool),
+
 		Data: Item{
 			array: js.Array{js.NewValue("q.feed")},
 			Value: js.NewValue("q.data"),
@@ -118,6 +147,8 @@ func With(food Food, options ...seed.Option) *Feed {
 	}
 
 	f.Empty = not.True(f.boolean)
+	f.Loading = f.loading
+	f.Exhausted = f.exhausted
 
 	return f
 }
@@ -132,7 +163,13 @@ func (f *Feed) New(options ...seed.Option) seed.Seed {
 	var template = f.template
 	var feed = f.feed
 
-	template.With(css.SetSelector("#"+html.ID(feed)), html.AddClass("feed"), seed.Options(options))
+	//nested feeds are within the template of their parent, where ids become classes.
+	var selector = "#" + html.ID(feed)
+	if isNested(f.food) {
+		selector = "." + html.ID(feed)
+	}
+
+	template.With(css.SetSelector(selector), html.AddClass("feed"), seed.Options(options))
 
 	convertToClasses(template)
 
@@ -146,15 +183,43 @@ func (f *Feed) New(options ...seed.Option) seed.Seed {
 
 	mem, adr := f.boolean.Variable()
 
+	if isLive(f.food) {
+		var k key
+		feed.Load(&k)
+		if k.field == "" {
+			panic("feed.Live: live feeds must have a Key, so that changes can be applied to their items")
+		}
+	}
+
+	var paging = js.Null()
+	if isPaginated(f.food) {
+		paging = f.paging()
+	}
+
+	//operators are applied on the client, whenever their values change.
+	var source, ops = operators(f.food)
+	for _, op := range ops {
+		for _, value := range op.values() {
+			feed.With(clientside.OnChange(value, html.Element(feed).Run("onreshape")))
+		}
+	}
+
 	feed.With(
 		client.OnLoad(js.Func("s.feed.orf").Run(js.NewValue("q"), js.NewString(client.ID(feed)), js.NewString(client.ID(template)), js.NewFunction(func(q js.Ctx) {
-			q.Return(food2Data(f.food, q))
-		}), js.NewFunction(func(q js.Ctx) {
+			q.Return(food2Data(source, q))
+		}, "cursor"), js.NewFunction(func(q js.Ctx) {
 			q("return async function(q) {")
 			q(scripts)
 			q("};")
-		}), js.NewString(string(mem)), js.NewString(string(adr)))),
+		}), js.NewString(string(mem)), js.NewString(string(adr)), f.key(), paging, f.virtual(), js.NewBool(isLive(f.food)), f.shape(ops))),
 	)
 
+	//nested feeds are populated from their parent's item whenever it is rendered.
+	if isNested(f.food) {
+		feed.With(client.OnLoad(f.Refresh(), js.Script(func(q js.Ctx) {
+			q.Await(js.NewValue("%v.refreshed", html.Element(feed)))
+		})))
+	}
+
 	return feed
 }
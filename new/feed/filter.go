@@ -18,15 +18,26 @@ func (item Item) Previous() Item {
 	return item
 }
 
+type filtered struct {
+	food Food
+	fn   func(Item) client.Bool
+}
+
 //Filter filters the food on the client with a given filter function.
 func Filter(food Food, fn func(Item) client.Bool) Food {
-	return func(q js.Ctx) js.Value {
-		return js.NewValue(`(%v).filter(%v)`, food2Data(food, q), js.NewNormalFunction(func(q js.Ctx) {
-			q.Return(fn(Item{
-				Value: js.NewValue("value"),
-				Index: js.Number{js.NewValue("index")},
-				array: js.Array{js.NewValue("array")},
-			}))
-		}, "value", "index", "array"))
-	}
+	return filtered{food, fn}
+}
+
+func (f filtered) operand() Food { return f.food }
+
+func (f filtered) values() []client.Value { return nil }
+
+func (f filtered) operate(q js.Ctx, food js.Value, feed *Feed) js.Value {
+	return js.NewValue(`s.feed.filter(%v, %v)`, food, js.NewNormalFunction(func(q js.Ctx) {
+		q.Return(f.fn(Item{
+			Value: js.NewValue("value"),
+			Index: js.Number{js.NewValue("index")},
+			array: js.Array{js.NewValue("array")},
+		}))
+	}, "value", "index", "array"))
 }
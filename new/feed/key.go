@@ -0,0 +1,63 @@
+package feed
+
+import (
+	"reflect"
+	"strconv"
+
+	"qlova.org/seed"
+	"qlova.org/seed/client"
+	"qlova.org/seed/use/js"
+)
+
+type key struct {
+	field string
+}
+
+//Key identifies the items of the feed by the given field, so that refreshing the feed only adds, removes,
+//moves and updates the items that have changed, rather than recreating all of them. This keeps the focus,
+//scroll position and animations of the items that remain. If the feed has been mirrored with Feed.Into,
+//the field is the name of a field of the structure, otherwise it is the name of the field in the food.
+func Key(field string) seed.Option {
+	return seed.NewOption(func(c seed.Seed) {
+		c.Save(key{field})
+	})
+}
+
+//OnEnter is called on the elements of an item whenever the item is added to a keyed feed by a refresh.
+//It is compatible with transition.SetOnEnter.
+func OnEnter(f ...client.Script) seed.Option {
+	return client.On("feedenter", f...)
+}
+
+//OnExit is called on the elements of an item whenever the item is removed from a keyed feed by a refresh,
+//the item is removed once the script, and any transition started by it, has finished.
+//It is compatible with transition.SetOnExit.
+func OnExit(f ...client.Script) seed.Option {
+	return client.On("feedexit", f...)
+}
+
+//key returns a function that returns the key of an item, or null if the feed is not keyed.
+func (f *Feed) key() js.Value {
+	var k key
+	f.feed.Load(&k)
+
+	if k.field == "" {
+		return js.Null()
+	}
+
+	return js.NewNormalFunction(func(q js.Ctx) {
+		q.Return(js.NewValue("data[" + strconv.Quote(f.fieldName(k.field)) + "]"))
+	}, "data").GetValue()
+}
+
+//structureOf returns the struct type of the given structure, which may be a pointer.
+func structureOf(structure interface{}) reflect.Type {
+	var t = reflect.TypeOf(structure)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
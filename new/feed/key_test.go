@@ -0,0 +1,49 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+)
+
+type testItem struct {
+	ID    int    `json:"id,omitempty"`
+	Name  string `json:"-"`
+	Title string `json:"title"`
+	Count int
+}
+
+func TestFieldName(t *testing.T) {
+	var f = With(nil)
+	if name := f.fieldName("ID"); name != "ID" {
+		t.Fatal("expected fields of feeds without a structure to keep their name, not", name)
+	}
+
+	f.Into(&testItem{})
+	for field, name := range map[string]string{
+		"ID":      "id",
+		"Name":    "Name",
+		"Title":   "title",
+		"Count":   "Count",
+		"Missing": "Missing",
+	} {
+		if got := f.fieldName(field); got != name {
+			t.Errorf("%v: expected %v, got %v", field, name, got)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	if key := With(nil).key().String(); key != "null" {
+		t.Fatal("expected unkeyed feeds to have a null key, not", key)
+	}
+
+	if key := With(nil, Key("ID")).key().String(); !strings.Contains(key, `data["ID"]`) {
+		t.Fatal(key)
+	}
+
+	var f = With(nil, Key("ID"))
+	f.Into(&testItem{})
+	if key := f.key().String(); !strings.Contains(key, `data["id"]`) {
+		t.Fatal("expected the key to be named by its json tag, not", key)
+	}
+}
@@ -0,0 +1,394 @@
+package feed
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"qlova.org/seed/client"
+)
+
+//sequence numbers the changes of every topic, so that a snapshot can be taken before it is known which topic it belongs to.
+var sequence uint64
+
+//topics are the open topics by their id.
+var topics sync.Map
+
+//expiry is how long a topic without subscribers is kept, before it is forgotten.
+var expiry = 5 * time.Minute
+
+//swept is when the topics were last checked for expiry, in unix nanoseconds.
+var swept int64
+
+//streams are the open streams of Handler, they are closed by Shutdown.
+var streams = struct {
+	sync.Mutex
+	open map[chan struct{}]bool
+}{open: make(map[chan struct{}]bool)}
+
+//backlog is the number of recent changes that a topic keeps, so that clients can catch up after they reconnect.
+const backlog = 256
+
+type change struct {
+	seq uint64
+
+	Op   string          `json:"op"`
+	Item json.RawMessage `json:"item,omitempty"`
+	Key  json.RawMessage `json:"key,omitempty"`
+}
+
+//Topic broadcasts the changes of live food to every client that is displaying it, see Live.
+//A Topic can be shared by everyone or can be specific to a user, ie. created by each call of a Live function.
+//Topics that have had no subscribers for a few minutes are forgotten, until they are returned by a Live
+//function again, so they don't need to be closed.
+type Topic struct {
+	id string
+
+	mutex       sync.Mutex
+	changes     []change
+	dropped     uint64
+	subscribers map[chan change]bool
+
+	//idle is when the topic last had no subscribers.
+	idle time.Time
+}
+
+//NewTopic returns a new open topic.
+func NewTopic() *Topic {
+	var random [16]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		panic("feed.NewTopic: " + err.Error())
+	}
+
+	var t = &Topic{
+		id:          hex.EncodeToString(random[:]),
+		subscribers: make(map[chan change]bool),
+	}
+	t.register()
+	return t
+}
+
+//register makes the topic available to Handler, until it is closed or expires.
+func (t *Topic) register() {
+	sweep()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.subscribers == nil {
+		return
+	}
+	if len(t.subscribers) == 0 {
+		t.idle = time.Now()
+	}
+	topics.Store(t.id, t)
+}
+
+//sweep forgets the topics that have had no subscribers for longer than the expiry.
+func sweep() {
+	var now = time.Now()
+	var last = atomic.LoadInt64(&swept)
+	if now.Sub(time.Unix(0, last)) < expiry/5 || !atomic.CompareAndSwapInt64(&swept, last, now.UnixNano()) {
+		return
+	}
+
+	topics.Range(func(id, value interface{}) bool {
+		var t = value.(*Topic)
+		t.mutex.Lock()
+		if len(t.subscribers) == 0 && now.Sub(t.idle) > expiry {
+			topics.Delete(id)
+		}
+		t.mutex.Unlock()
+		return true
+	})
+}
+
+//Insert adds the item to the feeds of the topic.
+func (t *Topic) Insert(item interface{}) error {
+	return t.publish("insert", item, nil)
+}
+
+//Update replaces the item with the same key in the feeds of the topic.
+func (t *Topic) Update(item interface{}) error {
+	return t.publish("update", item, nil)
+}
+
+//Delete removes the item with the given key from the feeds of the topic.
+func (t *Topic) Delete(key interface{}) error {
+	return t.publish("delete", nil, key)
+}
+
+//Close closes the topic, clients that are subscribed to it refresh their feeds.
+func (t *Topic) Close() {
+	topics.Delete(t.id)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for subscriber := range t.subscribers {
+		close(subscriber)
+	}
+	t.subscribers = nil
+}
+
+func (t *Topic) publish(op string, item, key interface{}) error {
+	var c = change{Op: op}
+
+	if item != nil {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("could not encode feed item: %w", err)
+		}
+		c.Item = encoded
+	}
+	if key != nil {
+		encoded, err := json.Marshal(key)
+		if err != nil {
+			return fmt.Errorf("could not encode feed key: %w", err)
+		}
+		c.Key = encoded
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	c.seq = atomic.AddUint64(&sequence, 1)
+
+	t.changes = append(t.changes, c)
+	if len(t.changes) > backlog {
+		t.dropped = t.changes[0].seq
+		t.changes = append(t.changes[:0], t.changes[1:]...)
+	}
+
+	for subscriber := range t.subscribers {
+		select {
+		case subscriber <- c:
+		default:
+			//Slow clients catch up from the backlog when they reconnect.
+			delete(t.subscribers, subscriber)
+			close(subscriber)
+
+			if len(t.subscribers) == 0 {
+				t.idle = time.Now()
+			}
+		}
+	}
+
+	return nil
+}
+
+//subscribe returns the changes after since, along with a channel of the changes that follow them.
+//Returns false if changes after since have been dropped, the client then needs to resync.
+func (t *Topic) subscribe(since uint64) ([]change, chan change, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if since < t.dropped || t.subscribers == nil {
+		return nil, nil, false
+	}
+
+	var missed []change
+	for _, c := range t.changes {
+		if c.seq > since {
+			missed = append(missed, c)
+		}
+	}
+
+	var subscriber = make(chan change, backlog)
+	t.subscribers[subscriber] = true
+	return missed, subscriber, true
+}
+
+func (t *Topic) unsubscribe(subscriber chan change) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.subscribers[subscriber] {
+		delete(t.subscribers, subscriber)
+		close(subscriber)
+	}
+	if len(t.subscribers) == 0 {
+		t.idle = time.Now()
+	}
+}
+
+type snapshot struct {
+	Items interface{} `json:"items"`
+	Topic string      `json:"topic"`
+	Since uint64      `json:"since"`
+}
+
+type live struct {
+	f    interface{}
+	args []client.Value
+}
+
+//Live returns food that is kept up to date by the server. The Go function is called with the given args
+//and returns a snapshot of the items along with the Topic that their changes are published to, optionally
+//followed by an error, ie.
+//
+//	func() ([]Row, *feed.Topic, error)
+//
+//Live feeds must have a Key, changes are applied to the item with the same key as they are published.
+//Clients that lose their connection catch up when they reconnect, or refresh if they have missed too much.
+func Live(f interface{}, args ...client.Value) Food {
+	var value = reflect.ValueOf(f)
+	var t = value.Type()
+
+	if t.Kind() != reflect.Func || t.NumOut() < 2 || t.NumOut() > 3 || t.Out(1) != reflect.TypeOf(&Topic{}) ||
+		(t.NumOut() == 3 && t.Out(2) != reflect.TypeOf([0]error{}).Elem()) {
+		panic("feed.Live: Must pass a Go function that returns items and a *feed.Topic, optionally followed by an error, not a " + t.String())
+	}
+
+	var in = make([]reflect.Type, t.NumIn())
+	for i := range in {
+		in[i] = t.In(i)
+	}
+
+	var out = []reflect.Type{reflect.TypeOf(snapshot{}), reflect.TypeOf([0]error{}).Elem()}
+
+	var wrapped = reflect.MakeFunc(reflect.FuncOf(in, out, t.IsVariadic()), func(args []reflect.Value) []reflect.Value {
+		//Changes published whilst the snapshot is being taken are sent again to the client.
+		var since = atomic.LoadUint64(&sequence)
+
+		var results []reflect.Value
+		if t.IsVariadic() {
+			results = value.CallSlice(args)
+		} else {
+			results = value.Call(args)
+		}
+
+		if len(results) == 3 && !results[2].IsNil() {
+			return []reflect.Value{reflect.Zero(out[0]), results[2]}
+		}
+
+		var s = snapshot{Items: results[0].Interface(), Since: since}
+		if topic := results[1].Interface().(*Topic); topic != nil {
+			topic.register()
+			s.Topic = topic.id
+		}
+
+		return []reflect.Value{reflect.ValueOf(s), reflect.Zero(out[1])}
+	})
+
+	return live{wrapped.Interface(), args}
+}
+
+//isLive returns true if the food is live, either directly or through operators such as Filter.
+func isLive(food Food) bool {
+	source, _ := operators(food)
+	_, ok := source.(live)
+	return ok
+}
+
+//Handler streams the changes of the topic with the given id to a client, as server-sent events.
+func Handler(w http.ResponseWriter, r *http.Request, id string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	//Reconnecting clients continue from the last change that they received.
+	var last = r.Header.Get("Last-Event-ID")
+	if last == "" {
+		last = r.URL.Query().Get("since")
+	}
+	since, err := strconv.ParseUint(last, 10, 64)
+
+	var missed []change
+	var subscriber chan change
+
+	if topic, ok := topics.Load(id); ok && err == nil {
+		missed, subscriber, ok = topic.(*Topic).subscribe(since)
+		if ok {
+			defer topic.(*Topic).unsubscribe(subscriber)
+		}
+	}
+
+	if subscriber == nil {
+		fmt.Fprint(w, "event: resync\ndata: \n\n")
+		flusher.Flush()
+		return
+	}
+
+	var send = func(c change) bool {
+		encoded, err := json.Marshal(c)
+		if err != nil {
+			log.Println(err)
+			return true
+		}
+		_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", c.seq, encoded)
+		return err == nil
+	}
+
+	for _, c := range missed {
+		if !send(c) {
+			return
+		}
+	}
+	flusher.Flush()
+
+	var heartbeat = time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+
+	var shutdown = make(chan struct{})
+	streams.Lock()
+	streams.open[shutdown] = true
+	streams.Unlock()
+	defer func() {
+		streams.Lock()
+		delete(streams.open, shutdown)
+		streams.Unlock()
+	}()
+
+	for {
+		select {
+		case c, ok := <-subscriber:
+			if !ok {
+				//The client was too slow, or the topic was closed.
+				return
+			}
+			if !send(c) {
+				return
+			}
+			flusher.Flush()
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case <-r.Context().Done():
+			return
+
+		case <-shutdown:
+			return
+		}
+	}
+}
+
+//Shutdown closes the open streams of Handler, so that a server can shutdown without waiting for them.
+//Clients reconnect and catch up with their feeds once the server is available again.
+func Shutdown() {
+	streams.Lock()
+	defer streams.Unlock()
+
+	for shutdown := range streams.open {
+		close(shutdown)
+		delete(streams.open, shutdown)
+	}
+}
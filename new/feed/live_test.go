@@ -0,0 +1,186 @@
+package feed
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//stream opens the stream of the topic with Handler, continuing after since.
+func stream(t *testing.T, id string, since uint64) (*bufio.Scanner, func()) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Handler(w, r, id)
+	}))
+
+	request, _ := http.NewRequest("GET", server.URL, nil)
+	request.Header.Set("Last-Event-ID", strconv.FormatUint(since, 10))
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+
+	return bufio.NewScanner(response.Body), func() {
+		response.Body.Close()
+		server.Close()
+	}
+}
+
+//next returns the next event of the stream, ie. "id: 1 data: {...}"
+func next(scanner *bufio.Scanner) string {
+	var lines []string
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			if len(lines) > 0 {
+				break
+			}
+			continue
+		}
+		lines = append(lines, scanner.Text())
+	}
+	return strings.Join(lines, " ")
+}
+
+func TestTopicCatchUp(t *testing.T) {
+	var topic = NewTopic()
+	defer topic.Close()
+
+	var since = atomic.LoadUint64(&sequence)
+	topic.Insert(map[string]int{"id": 1})
+	topic.Update(map[string]int{"id": 1})
+	topic.Delete(1)
+
+	events, done := stream(t, topic.id, since+1)
+	defer done()
+
+	if event := next(events); !strings.Contains(event, `"op":"update"`) {
+		t.Fatal(event)
+	}
+	if event := next(events); event != "id: "+strconv.FormatUint(since+3, 10)+` data: {"op":"delete","key":1}` {
+		t.Fatal(event)
+	}
+
+	//Changes published after the client caught up are streamed.
+	topic.Insert(map[string]int{"id": 2})
+	if event := next(events); !strings.Contains(event, `"op":"insert","item":{"id":2}`) {
+		t.Fatal(event)
+	}
+}
+
+func TestTopicResync(t *testing.T) {
+	var topic = NewTopic()
+	defer topic.Close()
+
+	var since = atomic.LoadUint64(&sequence)
+	for i := 0; i <= backlog; i++ {
+		topic.Insert(i)
+	}
+
+	events, done := stream(t, topic.id, since)
+	defer done()
+
+	if event := next(events); event != "event: resync data: " {
+		t.Fatal("expected a resync once the backlog overflows, not", event)
+	}
+
+	//Clients that kept up with the backlog catch up.
+	events, done = stream(t, topic.id, since+1)
+	defer done()
+
+	if event := next(events); !strings.Contains(event, `"item":1}`) {
+		t.Fatal(event)
+	}
+}
+
+func TestTopicClosed(t *testing.T) {
+	var topic = NewTopic()
+
+	var since = atomic.LoadUint64(&sequence)
+	events, done := stream(t, topic.id, since)
+	defer done()
+
+	topic.Insert(1)
+	if event := next(events); !strings.Contains(event, `"op":"insert"`) {
+		t.Fatal(event)
+	}
+
+	topic.Close()
+	if event := next(events); event != "" {
+		t.Fatal("expected the stream to end, not", event)
+	}
+
+	events, done = stream(t, topic.id, since)
+	defer done()
+
+	if event := next(events); event != "event: resync data: " {
+		t.Fatal("expected a resync for a closed topic, not", event)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	var topic = NewTopic()
+	defer topic.Close()
+
+	events, done := stream(t, topic.id, atomic.LoadUint64(&sequence))
+	defer done()
+
+	var ended = make(chan string)
+	go func() {
+		ended <- next(events)
+	}()
+
+	//Wait for the stream to open.
+	for i := 0; ; i++ {
+		streams.Lock()
+		var open = len(streams.open)
+		streams.Unlock()
+		if open > 0 {
+			break
+		}
+		if i > 100 {
+			t.Fatal("the stream did not open")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	Shutdown()
+
+	select {
+	case event := <-ended:
+		if event != "" {
+			t.Fatal(event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stream was not closed by Shutdown")
+	}
+}
+
+func TestTopicExpiry(t *testing.T) {
+	defer func(previous time.Duration) { expiry = previous }(expiry)
+	expiry = 10 * time.Millisecond
+
+	var topic = NewTopic()
+	defer topic.Close()
+
+	time.Sleep(20 * time.Millisecond)
+	NewTopic().Close()
+
+	if _, ok := topics.Load(topic.id); ok {
+		t.Fatal("expected an idle topic to be forgotten")
+	}
+
+	//Live functions make their topics available again.
+	var f = Live(func() ([]int, *Topic) { return nil, topic }).(live).f.(func() (snapshot, error))
+	if s, err := f(); err != nil || s.Topic != topic.id {
+		t.Fatal(s, err)
+	}
+	if _, ok := topics.Load(topic.id); !ok {
+		t.Fatal("expected the topic to be registered by Live")
+	}
+}
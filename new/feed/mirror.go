@@ -1,14 +1,147 @@
 package feed
 
 import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
 	"qlova.org/seed/client"
 	"qlova.org/seed/use/js"
 )
 
 //Into uses the mirror package to create an internal mirror to the given structure.
 //The structure can then be used for type-safe field access. Panics if the structure is invalid or unsupported.
+//
+//Scalar fields are selected by their value, ie. String(row.Name). Any field of a structure that is passed by
+//pointer can also be selected by its address, ie. Time(&row.Created), StringAt(&row.Customer.Name) or Nested(&row.Lines).
+//
+//Into modifies the structure: every nil pointer field is allocated (recursively), so that optional fields
+//can be selected by the pointer itself, ie. StringAt(row.Note). Pass a structure that is dedicated to the feed,
+//such as a new variable, never one that is shared or in use.
 func (f *Feed) Into(structure interface{}) {
 	f.mirror.Reflect(structure)
+	f.structure = structureOf(structure)
+
+	if value := reflect.ValueOf(structure); value.Kind() == reflect.Ptr && !value.IsNil() {
+		allocate(value.Elem(), nil)
+		f.into = value
+	}
+}
+
+//allocate allocates the nil pointer fields of the structure, recursively.
+func allocate(value reflect.Value, parents []reflect.Type) {
+	if value.Kind() != reflect.Struct {
+		return
+	}
+	for _, parent := range parents {
+		if parent == value.Type() {
+			return
+		}
+	}
+	parents = append(parents, value.Type())
+
+	for i := 0; i < value.NumField(); i++ {
+		var field = value.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			field = field.Elem()
+		}
+		allocate(field, parents)
+	}
+}
+
+//jsonName returns the name of the struct field in its JSON encoding, embedded structs without a name return "".
+//Returns false if the field is not encoded.
+func jsonName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" && !field.Anonymous {
+		return "", false
+	}
+
+	var tag = strings.Split(field.Tag.Get("json"), ",")[0]
+	if tag == "-" {
+		return "", false
+	}
+	if tag != "" {
+		return tag, true
+	}
+
+	var t = field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if field.Anonymous && t.Kind() == reflect.Struct {
+		return "", true
+	}
+	if field.PkgPath != "" {
+		return "", false
+	}
+	return field.Name, true
+}
+
+//locate returns the JSON path of the field that the pointer points to, within the given structure.
+func locate(value reflect.Value, pointer reflect.Value) ([]string, bool) {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, false
+		}
+		if value.Pointer() == pointer.Pointer() && value.Type() == pointer.Type() {
+			return []string{}, true
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		name, ok := jsonName(value.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		var field = value.Field(i)
+
+		var path []string
+		if field.CanAddr() && field.Addr().Pointer() == pointer.Pointer() && field.Addr().Type() == pointer.Type() {
+			path = []string{}
+		} else if path, ok = locate(field, pointer); !ok {
+			continue
+		}
+
+		if name != "" {
+			path = append([]string{name}, path...)
+		}
+		return path, true
+	}
+
+	return nil, false
+}
+
+//path returns the JSON path of the field, which is a pointer to a field of the structure passed to Into.
+func (f *Feed) path(field interface{}) js.Value {
+	var pointer = reflect.ValueOf(field)
+	if pointer.Kind() != reflect.Ptr || pointer.IsNil() || !f.into.IsValid() {
+		panic("feed: fields must be selected by a pointer into the structure passed to Feed.Into")
+	}
+
+	path, ok := locate(f.into, pointer)
+	if !ok {
+		panic("feed: field is not part of the structure passed to Feed.Into")
+	}
+
+	encoded, _ := json.Marshal(path)
+	return js.NewValue(string(encoded))
+}
+
+//at returns the field of the item, or the fallback if the field (or any of its parents) is missing.
+func (f *Feed) at(item js.AnyValue, field interface{}, fallback js.AnyValue) js.Value {
+	return js.NewValue("s.feed.at(%v, %v, %v)", item, f.path(field), fallback)
 }
 
 //String uses the mirror package to identify a field from its string value.
@@ -49,7 +182,7 @@ func (f *Feed) Float32(field float32) client.Float {
 
 //Float64 uses the mirror package to identify a field from its float64 value.
 //The field must be a field previously initialised by Feed.Into
-func (f *Feed) Float64(field float32) client.Float {
+func (f *Feed) Float64(field float64) client.Float {
 	return js.Number{Value: js.NewValue("%v"+f.mirror.Path(field), f.Data)}
 }
 
@@ -58,3 +191,54 @@ func (f *Feed) Float64(field float32) client.Float {
 func (f *Feed) Bool(field bool) client.Bool {
 	return js.Bool{Value: js.NewValue("%v"+f.mirror.Path(field), f.Data)}
 }
+
+//StringAt uses the address of the string field to identify it, the field can be nested or optional.
+//Missing fields are empty.
+func (f *Feed) StringAt(field *string) client.String {
+	return js.String{Value: f.at(f.Data, field, js.NewString(""))}
+}
+
+//IntAt uses the address of the int field to identify it, the field can be nested or optional.
+//Missing fields are zero.
+func (f *Feed) IntAt(field *int) client.Int {
+	return js.Number{Value: f.at(f.Data, field, js.NewNumber(0))}
+}
+
+//Int64At uses the address of the int64 field to identify it, the field can be nested or optional.
+//Missing fields are zero.
+func (f *Feed) Int64At(field *int64) client.Int {
+	return js.Number{Value: f.at(f.Data, field, js.NewNumber(0))}
+}
+
+//Float64At uses the address of the float64 field to identify it, the field can be nested or optional.
+//Missing fields are zero.
+func (f *Feed) Float64At(field *float64) client.Float {
+	return js.Number{Value: f.at(f.Data, field, js.NewNumber(0))}
+}
+
+//BoolAt uses the address of the bool field to identify it, the field can be nested or optional.
+//Missing fields are false.
+func (f *Feed) BoolAt(field *bool) client.Bool {
+	return js.Bool{Value: f.at(f.Data, field, js.NewBool(false))}
+}
+
+//Time uses the address of the time field to identify it, the field can be nested or optional.
+//Missing fields are NaN.
+func (f *Feed) Time(field *time.Time) client.Time {
+	return date{js.NewValue("Date.parse(%v)", f.at(f.Data, field, js.NewString("")))}
+}
+
+type date struct {
+	js.Value
+}
+
+//GetTime implements client.Time
+func (d date) GetTime() js.Value {
+	return d.Value
+}
+
+//Has returns true if the field of the current item is present and not null, the field is a pointer into the
+//structure passed to Feed.Into, or an optional (pointer) field, ie. Has(row.Note)
+func (f *Feed) Has(field interface{}) client.Bool {
+	return js.Bool{Value: js.NewValue("(%v != null)", f.at(f.Data, field, js.Null()))}
+}
@@ -0,0 +1,122 @@
+package feed
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testBase struct {
+	ID int `json:"id"`
+}
+
+type Extra struct {
+	Flag bool `json:"flag"`
+}
+
+type testCustomer struct {
+	Name     string
+	Referrer *testCustomer
+}
+
+type testRow struct {
+	testBase
+	*Extra
+
+	Name     string `json:"name,omitempty"`
+	Secret   string `json:"-"`
+	hidden   string
+	Customer *testCustomer `json:"customer"`
+	Note     *string
+	Lines    []int `json:"lines"`
+}
+
+func TestJSONName(t *testing.T) {
+	var structure = reflect.TypeOf(testRow{})
+
+	for _, test := range []struct {
+		field string
+		name  string
+		ok    bool
+	}{
+		{"testBase", "", true},
+		{"Extra", "", true},
+		{"Name", "name", true},
+		{"Secret", "", false},
+		{"hidden", "", false},
+		{"Customer", "customer", true},
+		{"Note", "Note", true},
+	} {
+		field, _ := structure.FieldByName(test.field)
+		if name, ok := jsonName(field); name != test.name || ok != test.ok {
+			t.Errorf("%v: expected %q %v, got %q %v", test.field, test.name, test.ok, name, ok)
+		}
+	}
+}
+
+func TestLocate(t *testing.T) {
+	var row testRow
+	allocate(reflect.ValueOf(&row).Elem(), nil)
+
+	if row.Extra == nil || row.Customer == nil || row.Customer.Referrer == nil || row.Note == nil {
+		t.Fatal("expected the nil pointer fields to be allocated")
+	}
+	if row.Customer.Referrer.Referrer != nil {
+		t.Fatal("expected recursive structures to be allocated once")
+	}
+
+	var f = Feed{into: reflect.ValueOf(&row)}
+	var other string
+
+	for _, test := range []struct {
+		field interface{}
+		path  string
+	}{
+		{&row.ID, `["id"]`},
+		{&row.Flag, `["flag"]`},
+		{&row.Name, `["name"]`},
+		{&row.Secret, ""},
+		{row.Customer, `["customer"]`},
+		{&row.Customer.Name, `["customer","Name"]`},
+		{&row.Customer.Referrer.Name, `["customer","Referrer","Name"]`},
+		{row.Note, `["Note"]`},
+		{&row.Lines, `["lines"]`},
+		{&other, ""},
+	} {
+		if _, ok := locate(f.into, reflect.ValueOf(test.field)); ok != (test.path != "") {
+			t.Errorf("%T: expected located to be %v", test.field, test.path != "")
+			continue
+		}
+		if test.path == "" {
+			continue
+		}
+		if path := f.path(test.field).String(); path != test.path {
+			t.Errorf("expected %v, got %v", test.path, path)
+		}
+	}
+}
+
+func TestPathPanics(t *testing.T) {
+	var row testRow
+	allocate(reflect.ValueOf(&row).Elem(), nil)
+
+	var other string
+	for _, test := range []struct {
+		feed  Feed
+		field interface{}
+		panic string
+	}{
+		{Feed{}, &row.Name, "must be selected by a pointer"},
+		{Feed{into: reflect.ValueOf(&row)}, row.Name, "must be selected by a pointer"},
+		{Feed{into: reflect.ValueOf(&row)}, &other, "not part of the structure"},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(r.(string), test.panic) {
+					t.Errorf("expected a panic containing %q, got %v", test.panic, r)
+				}
+			}()
+			test.feed.path(test.field)
+		}()
+	}
+}
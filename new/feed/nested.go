@@ -0,0 +1,42 @@
+package feed
+
+import (
+	"reflect"
+
+	"qlova.org/seed/use/js"
+)
+
+type nested struct {
+	parent *Feed
+	path   js.Value
+}
+
+//Nested returns food made of a slice field of the current item of the feed, so that a feed can be placed
+//within the items of this feed, ie. the lines of an order:
+//
+//	var lines = feed.With(orders.Nested(&order.Lines), feed.Key("ID"))
+//	lines.Into(&line)
+//
+//The field is a pointer into the structure passed to Feed.Into. The nested feed refreshes whenever
+//its item is rendered and is empty if the field is missing.
+func (f *Feed) Nested(field interface{}) Food {
+	var t = reflect.TypeOf(field)
+	if t == nil || t.Kind() != reflect.Ptr {
+		panic("feed.Nested: must pass a pointer to a slice field")
+	}
+	if t = t.Elem(); t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		panic("feed.Nested: must pass a pointer to a slice field, not a " + reflect.TypeOf(field).String())
+	}
+
+	return nested{f, f.path(field)}
+}
+
+//isNested returns true if the food is nested, either directly or through operators such as Filter.
+func isNested(food Food) bool {
+	source, _ := operators(food)
+	_, ok := source.(nested)
+	return ok
+}
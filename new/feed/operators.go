@@ -0,0 +1,201 @@
+package feed
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"qlova.org/seed/client"
+	"qlova.org/seed/use/js"
+)
+
+//operator is food that operates on the items of other food, on the client.
+//Operators are applied to the items that have been loaded, so that they can be applied again
+//whenever their values change, without loading the items again.
+type operator interface {
+	operand() Food
+
+	//values are the client values that the operator depends on.
+	values() []client.Value
+
+	operate(q js.Ctx, food js.Value, f *Feed) js.Value
+}
+
+//operators returns the food that the operators of food operate on, along with the operators, innermost first.
+func operators(food Food) (Food, []operator) {
+	op, ok := food.(operator)
+	if !ok {
+		return food, nil
+	}
+	source, ops := operators(op.operand())
+	return source, append(ops, op)
+}
+
+//shape returns a function that applies the operators to a list of items, or null if there are no operators.
+func (f *Feed) shape(ops []operator) js.Value {
+	if len(ops) == 0 {
+		return js.Null()
+	}
+	return js.NewFunction(func(q js.Ctx) {
+		var food = js.NewValue("food")
+		for _, op := range ops {
+			food = op.operate(q, food, f)
+		}
+		q.Return(food)
+	}, "food").GetValue()
+}
+
+//fieldName returns the name of the field in the food, fields of the structure passed to Feed.Into
+//are named by their json tag, if they have one.
+func (f *Feed) fieldName(name string) string {
+	if f != nil && f.structure != nil {
+		if field, ok := f.structure.FieldByName(name); ok {
+			if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+				return tag
+			}
+		}
+	}
+	return name
+}
+
+//getter returns a function that returns the given field of an item. The field is either the name of a field,
+//a Field, a client.String with the name of the field, or a pointer to a field of the structure passed to Feed.Into.
+func (f *Feed) getter(field interface{}) js.Value {
+	var path string
+
+	switch v := field.(type) {
+	case string:
+		path = "[" + strconv.Quote(f.fieldName(v)) + "]"
+	case Field:
+		path = "[" + strconv.Quote(f.fieldName(v.FieldName())) + "]"
+	case client.String:
+		return js.NewNormalFunction(func(q js.Ctx) {
+			q.Return(js.NewValue(`item[%v]`, v))
+		}, "item").GetValue()
+	default:
+		var value = reflect.ValueOf(field)
+		if value.Kind() == reflect.Ptr && !value.IsNil() {
+			//fields that are nested or optional are located by their address.
+			if f.into.IsValid() {
+				if _, ok := locate(f.into, value); ok {
+					return js.NewNormalFunction(func(q js.Ctx) {
+						q.Return(f.at(js.NewValue("item"), field, js.Null()))
+					}, "item").GetValue()
+				}
+			}
+			field = value.Elem().Interface()
+		}
+		path = f.mirror.Path(field)
+	}
+
+	return js.NewNormalFunction(func(q js.Ctx) {
+		q.Return(js.NewValue("item" + path))
+	}, "item").GetValue()
+}
+
+type sorted struct {
+	food       Food
+	field      interface{}
+	descending client.Bool
+}
+
+//Sort sorts the food on the client by the given field, in ascending order unless descending is true.
+//The field is either the name of a field, a Field, a client.String with the name of the field (so that
+//it can be chosen at runtime), or a pointer to a field of the structure passed to Feed.Into, ie. &row.Name
+func Sort(food Food, field interface{}, descending client.Bool) Food {
+	if descending == nil {
+		descending = client.NewBool(false)
+	}
+	return sorted{food, field, descending}
+}
+
+func (s sorted) operand() Food { return s.food }
+
+func (s sorted) values() []client.Value {
+	var values = []client.Value{s.descending}
+	if value, ok := s.field.(client.Value); ok {
+		values = append(values, value)
+	}
+	return values
+}
+
+func (s sorted) operate(q js.Ctx, food js.Value, f *Feed) js.Value {
+	return js.NewValue(`s.feed.sort(%v, %v, %v)`, food, f.getter(s.field), s.descending)
+}
+
+type grouped struct {
+	food  Food
+	field interface{}
+}
+
+//GroupBy groups the items of the food on the client by the given field, selected like Sort, so that the
+//items of each group follow each other. Groups are in the order of their first item. Feed.Group and
+//Feed.FirstOfGroup can be used to render a header at the start of each group.
+func GroupBy(food Food, field interface{}) Food {
+	return grouped{food, field}
+}
+
+func (g grouped) operand() Food { return g.food }
+
+func (g grouped) values() []client.Value {
+	if value, ok := g.field.(client.Value); ok {
+		return []client.Value{value}
+	}
+	return nil
+}
+
+func (g grouped) operate(q js.Ctx, food js.Value, f *Feed) js.Value {
+	return js.NewValue(`s.feed.group(%v, %v)`, food, f.getter(g.field))
+}
+
+//Group is the group of the item, see GroupBy.
+func (f *Feed) Group() client.String {
+	return js.String{Value: js.NewValue("q.group")}
+}
+
+//FirstOfGroup is true if the item is the first item of its group, see GroupBy.
+func (f *Feed) FirstOfGroup() client.Bool {
+	return js.Bool{Value: js.NewValue("(!!q.header)")}
+}
+
+type searched struct {
+	food   Food
+	query  client.String
+	fields []interface{}
+}
+
+//Search only keeps the items of the food that fuzzily match the query in any of the given fields, selected
+//like Sort. The closest matches come first. All of the items are kept when the query is empty.
+func Search(food Food, query client.String, fields ...interface{}) Food {
+	return searched{food, query, fields}
+}
+
+func (s searched) operand() Food { return s.food }
+
+func (s searched) values() []client.Value { return []client.Value{s.query} }
+
+func (s searched) operate(q js.Ctx, food js.Value, f *Feed) js.Value {
+	var getters = make(js.NewArray, len(s.fields))
+	for i, field := range s.fields {
+		getters[i] = f.getter(field)
+	}
+	return js.NewValue(`s.feed.search(%v, %v, %v)`, food, s.query, getters)
+}
+
+type limited struct {
+	food Food
+	n    client.Int
+}
+
+//Limit only keeps the first n items of the food.
+func Limit(food Food, n client.Int) Food {
+	return limited{food, n}
+}
+
+func (l limited) operand() Food { return l.food }
+
+func (l limited) values() []client.Value { return []client.Value{l.n} }
+
+func (l limited) operate(q js.Ctx, food js.Value, f *Feed) js.Value {
+	return js.NewValue(`s.feed.limit(%v, %v)`, food, l.n)
+}
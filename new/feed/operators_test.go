@@ -0,0 +1,49 @@
+package feed
+
+import (
+	"testing"
+
+	"qlova.org/seed/client"
+	"qlova.org/seed/client/clientside"
+)
+
+func TestOperators(t *testing.T) {
+	var (
+		query      = new(clientside.String)
+		descending = new(clientside.Bool)
+		field      = new(clientside.String)
+		n          = new(clientside.Int)
+	)
+
+	var source = Go(func() []testItem { return nil })
+	var food = Limit(Search(GroupBy(Sort(Filter(source, nil), field, descending), "Title"), query, "Title"), n)
+
+	root, ops := operators(food)
+	if _, ok := root.(rpc); !ok {
+		t.Fatal("expected the food that the operators operate on")
+	}
+
+	//operators are innermost first and refresh whenever their values change.
+	for i, expected := range [][]client.Value{
+		nil,
+		{descending, field},
+		nil,
+		{query},
+		{n},
+	} {
+		var values = ops[i].values()
+		if len(values) != len(expected) {
+			t.Fatalf("%T: expected %v values, got %v", ops[i], len(expected), len(values))
+		}
+		for j := range values {
+			if values[j] != expected[j] {
+				t.Errorf("%T: unexpected value %v", ops[i], j)
+			}
+		}
+	}
+
+	//Sort defaults to ascending.
+	if values := Sort(source, "Title", nil).(operator).values(); len(values) != 1 {
+		t.Fatal(values)
+	}
+}
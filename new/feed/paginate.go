@@ -0,0 +1,94 @@
+package feed
+
+import (
+	"reflect"
+
+	"qlova.org/seed/client"
+	"qlova.org/seed/client/clientside"
+	"qlova.org/seed/use/html"
+	"qlova.org/seed/use/js"
+)
+
+//Page is a page of paginated food, Next is the cursor of the following page and is empty on the last page.
+//A Page can be passed as the initial state of a paginated feed.
+type Page struct {
+	Items interface{} `json:"items"`
+	Next  string      `json:"next"`
+}
+
+type paginated struct {
+	f    interface{}
+	size int
+	args []client.Value
+}
+
+//Paginate returns food that is fed to the feed a page at a time. The Go function receives a cursor
+//and the page size, followed by the given args, and returns the items of the page along with the
+//cursor of the next page, optionally followed by an error, ie.
+//
+//	func(cursor string, size int) ([]Row, string, error)
+//
+//The cursor of the first page is empty, the function returns an empty cursor after the last page.
+//Like Go, the function can take a client.Request as the first argument.
+//More pages are loaded when the last item of the feed scrolls into view, or on Feed.LoadMore.
+func Paginate(f interface{}, size int, args ...client.Value) Food {
+	var value = reflect.ValueOf(f)
+	var t = value.Type()
+
+	if t.Kind() != reflect.Func || t.NumOut() < 2 || t.NumOut() > 3 || t.Out(1).Kind() != reflect.String ||
+		(t.NumOut() == 3 && t.Out(2) != reflect.TypeOf([0]error{}).Elem()) {
+		panic("feed.Paginate: Must pass a Go function that returns items and a cursor, optionally followed by an error, not a " + t.String())
+	}
+
+	var in = make([]reflect.Type, t.NumIn())
+	for i := range in {
+		in[i] = t.In(i)
+	}
+
+	var out = []reflect.Type{reflect.TypeOf(Page{}), reflect.TypeOf([0]error{}).Elem()}
+
+	var wrapped = reflect.MakeFunc(reflect.FuncOf(in, out, t.IsVariadic()), func(args []reflect.Value) []reflect.Value {
+		var results []reflect.Value
+		if t.IsVariadic() {
+			results = value.CallSlice(args)
+		} else {
+			results = value.Call(args)
+		}
+
+		var err = reflect.Zero(out[1])
+		if len(results) == 3 && !results[2].IsNil() {
+			err = results[2]
+		}
+
+		return []reflect.Value{reflect.ValueOf(Page{
+			Items: results[0].Interface(),
+			Next:  results[1].String(),
+		}), err}
+	})
+
+	return paginated{wrapped.Interface(), size, args}
+}
+
+//isPaginated returns true if the food is paginated, either directly or through operators such as Filter.
+func isPaginated(food Food) bool {
+	source, _ := operators(food)
+	_, ok := source.(paginated)
+	return ok
+}
+
+//LoadMore loads the next page of a paginated feed, it does nothing if the feed is already loading or is exhausted.
+func (f *Feed) LoadMore() client.Script {
+	return html.Element(f.feed).Run("onmore")
+}
+
+//paging returns the addresses of the clientside state of a paginated feed.
+func (f *Feed) paging() js.Value {
+	var state = func(b *clientside.Bool) js.Value {
+		mem, adr := b.Variable()
+		return js.NewValue(`[%v, %v]`, js.NewString(string(mem)), js.NewString(string(adr)))
+	}
+	return js.NewObject{
+		"loading":   state(f.loading),
+		"exhausted": state(f.exhausted),
+	}.GetValue()
+}
@@ -0,0 +1,65 @@
+package feed
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	var failed = errors.New("failed")
+
+	var pages = func(food Food) func(string, int) (Page, error) {
+		return food.(paginated).f.(func(string, int) (Page, error))
+	}
+
+	var items = pages(Paginate(func(cursor string, size int) ([]int, string, error) {
+		if cursor == "fail" {
+			return nil, "", failed
+		}
+		return []int{size}, cursor + "+", nil
+	}, 10))
+
+	page, err := items("", 2)
+	if err != nil || !reflect.DeepEqual(page, Page{Items: []int{2}, Next: "+"}) {
+		t.Fatal(page, err)
+	}
+	if _, err := items("fail", 2); err != failed {
+		t.Fatal("expected the error to be returned, got", err)
+	}
+
+	//The error is optional.
+	page, err = pages(Paginate(func(cursor string, size int) ([]string, string) {
+		return []string{cursor}, ""
+	}, 10))("a", 2)
+	if err != nil || !reflect.DeepEqual(page, Page{Items: []string{"a"}}) {
+		t.Fatal(page, err)
+	}
+
+	//Variadic functions receive the args as passed.
+	var variadic = Paginate(func(cursor string, size int, tags ...string) ([]string, string) {
+		return tags, ""
+	}, 10).(paginated).f.(func(string, int, ...string) (Page, error))
+	if page, _ := variadic("", 1, "a", "b"); !reflect.DeepEqual(page.Items, []string{"a", "b"}) {
+		t.Fatal(page)
+	}
+}
+
+func TestPaginateInvalid(t *testing.T) {
+	for _, f := range []interface{}{
+		"not a function",
+		func() []int { return nil },
+		func() ([]int, int) { return nil, 0 },
+		func() ([]int, string, string) { return nil, "", "" },
+		func() ([]int, string, error, error) { return nil, "", nil, nil },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected Paginate to panic for a %T", f)
+				}
+			}()
+			Paginate(f, 10)
+		}()
+	}
+}
@@ -7,7 +7,7 @@ import (
 
 func init() {
 	client.RegisterRenderer(func(c seed.Seed) []byte {
-		return []byte(`s.feed = {}; s.feed.onrefresh = (q, id, template_id, feed, exe, mem, adr) => {
+		return []byte(`s.feed = {}; s.feed.onrefresh = (q, id, template_id, feed, exe, mem, adr, key, paging, virtual, live, shape) => {
 			let l = q.get(id);
 			if (!l) return;
 
@@ -15,6 +15,7 @@ func init() {
 
 			if (!template) console.error("template missing", template_id);
 
+			if (virtual) s.feed.virtualize(q, l, template, exe, virtual);
 
 			l.onrefresh = () => {
 
@@ -22,16 +23,70 @@ func init() {
 				if (l.refreshing) return;
 				l.refreshing = true;
 
+				//pages that are still loading from before the refresh are discarded.
+				l.generation = (l.generation || 0) + 1;
+				if (l.observer) l.observer.disconnect();
+
+				//virtualized feeds only render the items in view, see feed.Virtualize
+				if (virtual) {
+					let failed = false;
+					l.refreshed = s.feed.food(id, feed).catch((e) => {
+						seed.report(e, l);
+						failed = true;
+					}).then(async(food) => {
+						if (!failed) {
+							food = await s.feed.shaped(l, s.feed.list(s.feed.page(l, food, paging, live)), shape);
+
+							await q.setvar(mem, adr, food.length > 0);
+							await s.feed.window(q, l, food, true);
+
+							if (l.onchange) await l.onchange();
+						}
+						l.refreshing = false;
+
+						if (paging) s.feed.paged(q, l, paging);
+						if (live && !failed) s.feed.subscribe(l);
+					});
+					return;
+				}
+
+				//keyed feeds are reconciled with the new food, see feed.Key
+				if (key) {
+					let failed = false;
+					l.refreshed = s.feed.food(id, feed).catch((e) => {
+						seed.report(e, l);
+						failed = true;
+					}).then(async(food) => {
+						if (!failed) {
+							food = await s.feed.shaped(l, s.feed.page(l, food, paging, live), shape);
+
+							await s.feed.reconcile(q, l, template, food, exe, mem, adr, key);
+
+							if (l.onchange) await l.onchange();
+
+							await seed.render(q, l);
+						}
+						l.refreshing = false;
+
+						if (paging) s.feed.paged(q, l, paging);
+						if (live && !failed) s.feed.subscribe(l);
+					});
+					return;
+				}
+
 				q.setvar(mem, adr, false);
 
 				//remove previous content.
 				l.textContent = "";
 
-				feed().catch((e) => {
+				l.refreshed = s.feed.food(id, feed).catch((e) => {
 					seed.report(e, l);
 
 					l.refreshing = false;
 				}).then(async(food) => {
+					food = await s.feed.shaped(l, s.feed.page(l, food, paging, live), shape);
+					l.food = [];
+
 					if (!food) {
 						l.refreshing = false;
 						return;
@@ -49,82 +104,787 @@ func init() {
 						await q.setvar(mem, adr, true);
 					}
 
-					let i = 0;
-					for (let piece of food) {
-						let clone = template.content.cloneNode(true);
-						let nodes = clone.children.length;
-	
-						//hacky tween fix
-						let update_tween = function(element) {
-							let key = element.getAttribute("data-flip-key");
-							if (key) element.setAttribute("data-flip-key", key+" "+i);
-							for (let child of element.children) {
-								update_tween(child);
-							};
-						};
-						for (let child of clone.children) {
-							update_tween(child);
-						}
-	
-						l.appendChild(clone);
-	
-						let offset = i*nodes;
-	
-						let ctx = new c.Ctx(q);
-						ctx.data = piece;
-						ctx.i = offset;
-						ctx.feed = food;
-
-						ctx.nodes = [];
-						for (let i = 0; i < nodes; i++) {
-							let child = l.children[offset+i];
-							ctx.nodes.push(child);
-						}
-
-						ctx.get = function(id) {
-							if (id instanceof HTMLElement) return id;
-							
-							let result;
-	
-							for (let i = 0; i < ctx.nodes.length; i++) {
-								let child = ctx.nodes[i];
-								if (!child) debugger;
-								if (child.className == id) return child;
-								result= child.querySelector("." + id);
-								if (result) return result;
-							}
-
-							let old = seed.get.cache;
-							seed.get.cache = null;
-							result = ctx.parent.get(id);
-							seed.get.cache = old;
-	
-							return result;
-						};
-	
-						try {
-							let f = await exe();
-							await f(ctx);
-						} catch(e) {
-							l.refreshing = false;
-							seed.report(e, l);
-						}
-	
-						for (let j = 0; j < nodes; j++) {
-							let child = l.children[offset+j];
-							child.setAttribute('data-id', i);
-						}
-						
-						i++;
-					}
+					l.food = food;
+					await s.feed.populate(q, l, template, food, 0, exe);
 
 					if (l.onchange) await l.onchange();
 
 					await seed.render(q, l);
 					l.refreshing = false;
+
+					if (paging) s.feed.paged(q, l, paging);
+					if (live) s.feed.subscribe(l);
 				});
 				
 			}
-		}; s.feed.orf = s.feed.onrefresh;`)
+
+			//update shows the food in the feed without refreshing it, appended is true
+			//if the food only has new items at the end.
+			let update = async (food, appended) => {
+				await q.setvar(mem, adr, food.length > 0);
+
+				if (virtual) {
+					if (!appended) l.virtual.reset = true;
+					await s.feed.window(q, l, food, false);
+				} else if (key) {
+					await s.feed.reconcile(q, l, template, food, exe, mem, adr, key);
+				} else {
+					let from = appended ? l.food.length : 0;
+					if (!appended) l.textContent = "";
+					l.food = food;
+					await s.feed.populate(q, l, template, food, from, exe);
+				}
+
+				if (l.onchange) await l.onchange();
+
+				if (!virtual) await seed.render(q, l);
+			};
+
+			//live feeds apply the changes that are published to them, see feed.Live
+			if (live) l.onlive = async (change) => {
+				//changes that are published during a refresh are part of the new snapshot.
+				if (l.refreshing) return;
+
+				let source = (l.source || []).slice();
+				let k = String(change.op == "delete" ? change.key : key(change.item));
+				let i = source.findIndex((item) => String(key(item)) == k);
+
+				if (change.op == "delete") {
+					if (i < 0) return;
+					source.splice(i, 1);
+				} else if (i < 0) {
+					source.push(change.item);
+				} else {
+					source[i] = change.item;
+				}
+
+				l.source = source;
+				await update(shape ? await shape(source) : source, false);
+			};
+
+			//paginated feeds load their next page on demand, see feed.Paginate
+			if (paging) l.onmore = async () => {
+				if (l.refreshing || l.loading || !l.next) return;
+				l.loading = true;
+
+				let generation = l.generation;
+				await s.feed.state(q, paging.loading, true);
+
+				let page;
+				try {
+					page = await feed(l.next);
+				} catch(e) {
+					seed.report(e, l);
+				}
+
+				if (page && generation == l.generation) {
+					let food = await s.feed.shaped(l, s.feed.list(s.feed.page(l, page, paging, live)), shape, true);
+
+					//shaped food may be reordered by the new items.
+					await update(food, !shape);
+				}
+
+				l.loading = false;
+				await s.feed.state(q, paging.loading, false);
+
+				if (page) s.feed.paged(q, l, paging);
+			};
+
+			//feeds with operators are shaped again whenever the values of the operators change, see feed.Sort
+			if (shape) l.onreshape = () => {
+				l.reshaped = (l.reshaped || Promise.resolve()).then(async () => {
+					if (l.refreshing || !l.source) return;
+
+					await update(await shape(l.source), false);
+
+					if (paging) s.feed.paged(q, l, paging);
+				}).catch((e) => seed.report(e, l));
+				return l.reshaped;
+			};
+		}; s.feed.orf = s.feed.onrefresh;
+
+		//populate adds the items of the food, from the given index, to the end of an unkeyed feed.
+		s.feed.populate = async (q, l, template, food, from, exe) => {
+			for (let i = from; i < food.length; i++) {
+				let piece = food[i];
+				let clone = template.content.cloneNode(true);
+				let nodes = clone.children.length;
+
+				//hacky tween fix
+				let update_tween = function(element) {
+					let key = element.getAttribute("data-flip-key");
+					if (key) element.setAttribute("data-flip-key", key+" "+i);
+					for (let child of element.children) {
+						update_tween(child);
+					};
+				};
+				for (let child of clone.children) {
+					update_tween(child);
+				}
+
+				l.appendChild(clone);
+
+				let offset = i*nodes;
+
+				let children = [];
+				for (let i = 0; i < nodes; i++) {
+					children.push(l.children[offset+i]);
+				}
+
+				let ctx = s.feed.ctx(q, children, piece, offset, food, i);
+
+				try {
+					let f = await exe();
+					await f(ctx);
+				} catch(e) {
+					seed.report(e, l);
+				}
+
+				for (let child of children) {
+					child.setAttribute('data-id', i);
+				}
+			}
+		};
+
+		//page returns the items of a page of paginated food and remembers the cursor of the next page.
+		//The items of live food are returned from its snapshot.
+		s.feed.page = (l, food, paging, live) => {
+			if (live && food && !Array.isArray(food) && "topic" in food) {
+				l.topic = food.topic;
+				l.since = food.since;
+				food = food.items || [];
+			}
+
+			if (!paging) return food;
+
+			if (food && !Array.isArray(food) && "items" in food) {
+				l.next = food.next || null;
+				return food.items || [];
+			}
+
+			l.next = null;
+			return food || [];
+		};
+
+		//paged updates the state of a paginated feed after a page has been loaded, the next page is
+		//loaded when the last item of the feed scrolls into view.
+		s.feed.paged = (q, l, paging) => {
+			s.feed.state(q, paging.exhausted, !l.next);
+
+			if (l.observer) l.observer.disconnect();
+			if (!l.next) return;
+
+			//virtualized feeds load more as they are scrolled.
+			if (l.virtual) {
+				s.feed.window(q, l);
+				return;
+			}
+
+			let last = l.lastElementChild;
+			if (l.items) {
+				let item = l.items[l.items.length-1];
+				last = item ? item.nodes[item.nodes.length-1] : null;
+			}
+
+			//filtered pages may be empty.
+			if (!last) {
+				l.onmore();
+				return;
+			}
+
+			if (!window.IntersectionObserver) return;
+
+			if (!l.observer) l.observer = new IntersectionObserver((entries) => {
+				for (let entry of entries) if (entry.isIntersecting) {
+					l.observer.disconnect();
+					l.onmore();
+					return;
+				}
+			}, {rootMargin: "200px"});
+
+			l.observer.observe(last);
+		};
+
+		//shaped remembers the items that have been loaded, then returns them after they have been shaped
+		//by the operators of the feed. more is true if the items follow the items that have already been loaded.
+		s.feed.shaped = async (l, items, shape, more) => {
+			if (shape) items = s.feed.list(items);
+			l.source = more ? (l.source || []).concat(items) : items;
+			return shape ? await shape(l.source) : l.source;
+		};
+
+		//at returns the field of the data at the given path, or the fallback if it is missing, see Feed.Into
+		s.feed.at = (data, path, fallback) => {
+			for (let name of path) {
+				if (data == null) break;
+				data = data[name];
+			}
+			return (data == null) ? fallback : data;
+		};
+
+		//list returns the food as a list of items.
+		s.feed.list = (food) => {
+			if (!food) return [];
+			if (!Array.isArray(food)) food = [food];
+			if (food.length == 1 && !food[0]) return [];
+			return food;
+		};
+
+		//virtualize sets up a virtualized feed, only the items within the view of the feed (and a view either side)
+		//are rendered, spacers before and after the items take up the space of the items that are not rendered.
+		s.feed.virtualize = (q, l, template, exe, options) => {
+			let v = l.virtual = {
+				options: options,
+				template: template,
+				exe: exe,
+				size: template.content.children.length,
+
+				height: 0, //the fixed height of an item.
+				heights: [], //the measured heights of the items.
+				measured: 0, total: 0, //the number and total height of the measured items.
+				offsets: null,
+
+				rows: [], //the items that are rendered.
+				spare: [], //the items that can be recycled.
+
+				before: document.createElement("div"),
+				after: document.createElement("div"),
+			};
+			v.before.style.flexShrink = v.after.style.flexShrink = "0";
+
+			let schedule = () => {
+				if (v.scheduled) return;
+				v.scheduled = true;
+				requestAnimationFrame(() => {
+					v.scheduled = false;
+					s.feed.window(q, l);
+				});
+			};
+
+			l.addEventListener("scroll", schedule, {passive: true});
+			if (window.ResizeObserver) new ResizeObserver(schedule).observe(l);
+
+			l.scrollto = async (index) => {
+				index = Math.max(0, index);
+
+				//measuring the items in view can move the item, so scroll until it stays put.
+				for (let tries = 0; tries < 4; tries++) {
+					let offset = s.feed.offset(l, index);
+					if (tries > 0 && Math.abs(l.scrollTop - offset) < 1) break;
+					l.scrollTop = offset;
+					await s.feed.window(q, l);
+				}
+			};
+		};
+
+		//offset returns the offset of the item at the given index, from the top of a virtualized feed.
+		s.feed.offset = (l, index) => {
+			let v = l.virtual;
+			let n = (l.food || []).length;
+			index = Math.min(index, n);
+
+			if (v.height) return index * v.height;
+
+			if (!v.offsets) {
+				let estimate = v.measured ? v.total / v.measured : 0;
+				v.offsets = new Float64Array(n + 1);
+				for (let i = 0; i < n; i++) v.offsets[i+1] = v.offsets[i] + (v.heights[i] || estimate);
+			}
+			return v.offsets[index];
+		};
+
+		//index returns the index of the item at the given offset from the top of a virtualized feed.
+		s.feed.index = (l, offset) => {
+			let n = (l.food || []).length;
+			let lo = 0, hi = n;
+			while (lo < hi) {
+				let mid = (lo + hi) >> 1;
+				if (s.feed.offset(l, mid+1) <= offset) lo = mid + 1; else hi = mid;
+			}
+			return lo;
+		};
+
+		//window renders the items within view of a virtualized feed, if food is given it replaces the items
+		//of the feed and if reset is true, all of the rendered items are updated.
+		s.feed.window = async (q, l, food, reset) => {
+			let v = l.virtual;
+			if (food) {
+				l.food = food;
+				v.offsets = null;
+				if (reset) {
+					v.heights = [];
+					v.measured = v.total = 0;
+					v.reset = true;
+				}
+			}
+
+			if (v.rendering) {
+				v.again = true;
+				return;
+			}
+			v.rendering = true;
+
+			try {
+				//newly measured items may change which items are in view.
+				for (let pass = 0; pass < 4; pass++) {
+					v.again = false;
+					if (!await s.feed.draw(q, l) && !v.again) break;
+				}
+			} finally {
+				v.rendering = false;
+			}
+		};
+
+		//draw renders the items within view of a virtualized feed, recycling the elements of items that have
+		//gone out of view. Returns true if items were measured for the first time.
+		s.feed.draw = async (q, l) => {
+			let v = l.virtual;
+			let food = l.food || [];
+			let n = food.length;
+
+			if (v.before.parentNode != l) l.insertBefore(v.before, l.firstChild);
+			if (v.after.parentNode != l) l.appendChild(v.after);
+
+			if (v.options.height && !v.height) {
+				let probe = document.createElement("div");
+				probe.style.height = v.options.height;
+				l.insertBefore(probe, v.after);
+				v.height = probe.getBoundingClientRect().height;
+				l.removeChild(probe);
+			}
+
+			//items that have not been measured are rendered in view.
+			let view = l.clientHeight || window.innerHeight;
+			let top = l.scrollTop - view;
+			let bottom = l.scrollTop + 2*view;
+
+			let start = 0, end = Math.min(1, n);
+
+			//the first item is measured to estimate the height of the others.
+			if (v.height || v.measured) {
+				start = Math.min(s.feed.index(l, Math.max(0, top)), n);
+				end = start;
+				while (end < n && s.feed.offset(l, end) < bottom) end++;
+			}
+
+			//recycle the items that are out of view.
+			let kept = new Map();
+			for (let row of v.rows) {
+				if (!v.reset && row.index >= start && row.index < end) {
+					kept.set(row.index, row);
+				} else {
+					for (let node of row.nodes) if (node.parentNode == l) l.removeChild(node);
+					v.spare.push(row);
+				}
+			}
+			v.reset = false;
+
+			let rows = [], bound = [];
+			for (let i = start; i < end; i++) {
+				let row = kept.get(i);
+				if (!row) {
+					row = v.spare.pop();
+					if (!row) row = {nodes: Array.prototype.slice.call(v.template.content.cloneNode(true).children)};
+					row.index = i;
+					bound.push(row);
+				}
+				rows.push(row);
+			}
+			v.rows = rows;
+
+			let ref = v.after;
+			for (let r = rows.length-1; r >= 0; r--) {
+				let nodes = rows[r].nodes;
+				for (let j = nodes.length-1; j >= 0; j--) {
+					if (nodes[j].parentNode != l || nodes[j].nextSibling != ref) l.insertBefore(nodes[j], ref);
+					ref = nodes[j];
+				}
+			}
+
+			for (let row of bound) {
+				try {
+					let f = await v.exe();
+					await f(s.feed.ctx(q, row.nodes, food[row.index], row.index*v.size, food, row.index));
+				} catch(e) {
+					seed.report(e, l);
+				}
+				for (let node of row.nodes) {
+					node.setAttribute('data-id', row.index);
+					await seed.render(q, node);
+				}
+			}
+
+			//the item at the top of the view stays put as the items are measured.
+			let anchor = s.feed.index(l, l.scrollTop);
+			let anchored = l.scrollTop - s.feed.offset(l, anchor);
+
+			let measured = false, changed = false;
+			if (!v.height) for (let row of bound) {
+				let height = 0;
+				for (let node of row.nodes) height += node.getBoundingClientRect().height;
+
+				let old = v.heights[row.index];
+				if (old == height) continue;
+				if (old == null) {
+					v.measured++;
+					measured = true;
+				} else {
+					v.total -= old;
+				}
+				v.total += height;
+				v.heights[row.index] = height;
+				v.offsets = null;
+				changed = true;
+			}
+
+			v.before.style.height = s.feed.offset(l, start) + "px";
+			v.after.style.height = (s.feed.offset(l, n) - s.feed.offset(l, end)) + "px";
+
+			if (changed && l.scrollTop > 0) l.scrollTop = s.feed.offset(l, anchor) + anchored;
+
+			//paginated feeds load more before they are scrolled to the end.
+			if (l.onmore && l.next && end >= n - (end - start)) l.onmore();
+
+			return measured;
+		};
+
+		//subscribe streams the changes of a live feed from its topic, the changes are applied in order.
+		//If the feed has missed changes, ie. after the network has been lost for too long, it is refreshed.
+		s.feed.subscribe = (l) => {
+			if (l.stream) l.stream.close();
+			l.stream = null;
+
+			if (!l.topic || !window.EventSource) return;
+
+			let stream = l.stream = new EventSource("/go/feed/" + l.topic + "?since=" + l.since);
+			let applied = Promise.resolve();
+
+			stream.onmessage = (event) => {
+				let change = JSON.parse(event.data);
+				applied = applied.then(() => l.onlive(change)).catch((e) => seed.report(e, l));
+			};
+
+			let resync = () => {
+				stream.close();
+				if (l.stream == stream) l.stream = null;
+				l.onrefresh();
+			};
+
+			stream.addEventListener("resync", resync);
+
+			//the browser reconnects on its own, unless the stream was closed for good.
+			stream.onerror = () => {
+				if (stream.readyState == EventSource.CLOSED && l.stream == stream) setTimeout(() => {
+					if (l.stream == stream) resync();
+				}, 3000);
+			};
+
+			if (!l.online) {
+				l.online = true;
+				window.addEventListener("online", () => {
+					if (l.stream && l.stream.readyState == EventSource.CLOSED) l.onrefresh();
+				});
+			}
+		};
+
+		s.feed.state = (q, variable, value) => q.setvar(variable[0], variable[1], value);
+
+		//sort sorts the items by the field returned by get, numbers are sorted by their value and strings are
+		//sorted naturally, missing values come last, see feed.Sort
+		s.feed.sort = (food, get, descending) => {
+			let order = descending ? -1 : 1;
+			return s.feed.list(food).slice().sort((a, b) => {
+				let x = get(a), y = get(b);
+				if (x == null || y == null) return (x == null) - (y == null);
+				if (typeof x == "number" && typeof y == "number") return order * (x - y);
+				return order * String(x).localeCompare(String(y), undefined, {numeric: true, sensitivity: "base"});
+			});
+		};
+
+		//group groups the items by the field returned by get, the groups are in the order of their first item.
+		//The group of each item is stored in the groups of the returned list, see feed.GroupBy
+		s.feed.group = (food, get) => {
+			let groups = new Map();
+			for (let item of s.feed.list(food)) {
+				let group = get(item);
+				group = (group == null) ? "" : String(group);
+				if (!groups.has(group)) groups.set(group, []);
+				groups.get(group).push(item);
+			}
+
+			let result = [];
+			result.groups = [];
+			for (let [group, items] of groups) {
+				for (let item of items) {
+					result.push(item);
+					result.groups.push(group);
+				}
+			}
+			return result;
+		};
+
+		//search keeps the items where any of the fields returned by getters fuzzily match the query,
+		//ordered by how closely they match, see feed.Search
+		s.feed.search = (food, query, getters) => {
+			let list = s.feed.list(food);
+
+			query = String(query || "").trim().toLowerCase();
+			if (!query) return list;
+
+			let terms = query.split(/\s+/);
+
+			let matches = [];
+			list.forEach((item, index) => {
+				let fields = getters.map((get) => {
+					let value = get(item);
+					return (value == null) ? "" : String(value).toLowerCase();
+				});
+
+				//every term has to match one of the fields.
+				let score = 0;
+				for (let term of terms) {
+					let best = 0;
+					for (let field of fields) best = Math.max(best, s.feed.fuzzy(term, field));
+					if (!best) return;
+					score += best;
+				}
+
+				matches.push({item: item, score: score, index: index});
+			});
+
+			matches.sort((a, b) => (b.score - a.score) || (a.index - b.index));
+			return matches.map((match) => match.item);
+		};
+
+		//fuzzy returns how closely the term matches the text, zero if the characters of the term do not appear
+		//in the text in order. Exact, prefix and consecutive matches score higher.
+		s.feed.fuzzy = (term, text) => {
+			if (text == term) return 1000;
+
+			let found = text.indexOf(term);
+			if (found == 0) return 500 + term.length;
+			if (found > 0) return 250 + term.length - found/text.length;
+
+			let score = 0, run = 0, j = 0;
+			for (let i = 0; i < term.length; i++) {
+				let next = text.indexOf(term[i], j);
+				if (next < 0) return 0;
+
+				run = (next == j) ? run + 1 : 1;
+				score += run;
+				j = next + 1;
+			}
+			return score;
+		};
+
+		//limit keeps the first n items, see feed.Limit
+		s.feed.limit = (food, n) => {
+			let list = s.feed.list(food);
+			let groups = list.groups;
+
+			list = list.slice(0, Math.max(0, n));
+			if (groups) list.groups = groups.slice(0, list.length);
+			return list;
+		};
+
+		//filter filters food, or a page of food, see feed.Filter
+		s.feed.filter = (food, fn) => {
+			if (food && !Array.isArray(food) && Array.isArray(food.items)) {
+				return Object.assign({}, food, {items: food.items.filter(fn)});
+			}
+			return (food).filter(fn);
+		};
+
+		//ctx returns the context that the scripts of an item run in, elements are looked up within its nodes.
+		s.feed.ctx = (q, nodes, data, i, food, index) => {
+			let ctx = new c.Ctx(q);
+			ctx.data = data;
+			ctx.i = i;
+			ctx.feed = food;
+			ctx.nodes = nodes;
+
+			//grouped food, see feed.GroupBy
+			if (food.groups) {
+				ctx.group = food.groups[index];
+				ctx.header = index == 0 || food.groups[index-1] !== ctx.group;
+			}
+
+			ctx.get = function(id) {
+				if (id instanceof HTMLElement) return id;
+				
+				let result;
+
+				for (let i = 0; i < ctx.nodes.length; i++) {
+					let child = ctx.nodes[i];
+					if (!child) debugger;
+					if (child.className == id) return child;
+					result= child.querySelector("." + id);
+					if (result) return result;
+				}
+
+				let old = seed.get.cache;
+				seed.get.cache = null;
+				result = ctx.parent.get(id);
+				seed.get.cache = old;
+
+				return result;
+			};
+
+			return ctx;
+		};
+
+		//reconcile updates the items of a keyed feed to match the food, items are matched by their key so that
+		//only the items that have changed are added, removed, moved or updated.
+		s.feed.reconcile = async (q, l, template, food, exe, mem, adr, key) => {
+			food = s.feed.list(food);
+
+			let first = !l.items;
+			l.food = food;
+			let size = template.content.children.length;
+
+			let old = new Map();
+			if (l.items) l.items.forEach((item, i) => old.set(item.key, {item: item, from: i}));
+
+			let items = [];
+			let seen = new Map();
+			food.forEach((piece, i) => {
+				let k = String(key(piece));
+
+				//duplicate keys are told apart by their occurrence.
+				let n = seen.get(k) || 0;
+				seen.set(k, n+1);
+				if (n > 0) k += "#" + n;
+
+				let match = old.get(k);
+				old.delete(k);
+
+				let group = food.groups ? JSON.stringify([food.groups[i], i == 0 || food.groups[i-1] !== food.groups[i]]) : null;
+
+				items.push({key: k, data: piece, index: i, group: group, old: match ? match.item : null, from: match ? match.from : -1});
+			});
+
+			let transitions = [];
+
+			//items that are no longer in the food exit, then they are removed.
+			for (let [, removed] of old) {
+				transitions.push(s.feed.transition(removed.item.nodes, "feedexit").then(() => {
+					for (let node of removed.item.nodes) if (node.parentNode == l) l.removeChild(node);
+				}));
+			}
+
+			await q.setvar(mem, adr, items.length > 0);
+
+			for (let item of items) {
+				if (item.old) {
+					item.nodes = item.old.nodes;
+					continue;
+				}
+
+				let clone = template.content.cloneNode(true);
+
+				//tweens follow the item.
+				let update_tween = function(element) {
+					let tween = element.getAttribute("data-flip-key");
+					if (tween) element.setAttribute("data-flip-key", tween+" "+item.key);
+					for (let child of element.children) {
+						update_tween(child);
+					};
+				};
+				for (let child of clone.children) {
+					update_tween(child);
+				}
+
+				item.nodes = Array.prototype.slice.call(clone.children);
+			}
+
+			//items that are in the same order as before stay where they are, the rest are moved around them.
+			let stationary = s.feed.stationary(items.map(item => item.from));
+			let ref = null;
+			for (let i = items.length-1; i >= 0; i--) {
+				let nodes = items[i].nodes;
+				if (!stationary.has(i)) {
+					for (let node of nodes) l.insertBefore(node, ref);
+				}
+				if (nodes.length) ref = nodes[0];
+			}
+
+			for (let item of items) {
+				let changed = !item.old || item.old.index != item.index || item.old.group != item.group ||
+					JSON.stringify(item.old.data) != JSON.stringify(item.data);
+
+				if (changed) {
+					try {
+						let f = await exe();
+						await f(s.feed.ctx(q, item.nodes, item.data, item.index*size, food, item.index));
+					} catch(e) {
+						seed.report(e, l);
+					}
+				}
+
+				for (let node of item.nodes) node.setAttribute('data-id', item.index);
+
+				if (!item.old && !first) transitions.push(s.feed.transition(item.nodes, "feedenter"));
+			}
+
+			l.items = items.map(item => ({key: item.key, data: item.data, index: item.index, group: item.group, nodes: item.nodes}));
+
+			await Promise.all(transitions);
+		};
+
+		//transition fires the event on the nodes of an item and their descendants,
+		//the returned promise resolves once any transitions that were started have finished.
+		s.feed.transition = (nodes, event) => {
+			let promises = [];
+			for (let node of nodes) {
+				for (let element of [node].concat(Array.prototype.slice.call(node.querySelectorAll("*")))) {
+					let handler = element["on"+event];
+					if (!handler) continue;
+
+					promises.push(handler());
+
+					if (seed.goto && seed.goto.in) {
+						promises.push(seed.goto.in);
+						seed.goto.in = null;
+					}
+					if (seed.goto && seed.goto.out) {
+						promises.push(seed.goto.out);
+						seed.goto.out = null;
+					}
+				}
+			}
+			return Promise.all(promises);
+		};
+
+		//stationary returns the indices of the longest increasing subsequence of the previous positions of the items,
+		//these items do not need to be moved. New items have a position of -1.
+		s.feed.stationary = (from) => {
+			let tails = [], previous = new Array(from.length);
+			for (let i = 0; i < from.length; i++) {
+				if (from[i] < 0) continue;
+
+				let lo = 0, hi = tails.length;
+				while (lo < hi) {
+					let mid = (lo + hi) >> 1;
+					if (from[tails[mid]] < from[i]) lo = mid + 1; else hi = mid;
+				}
+				previous[i] = lo > 0 ? tails[lo-1] : -1;
+				tails[lo] = i;
+			}
+
+			let result = new Set();
+			for (let i = tails.length ? tails[tails.length-1] : -1; i >= 0; i = previous[i]) result.add(i);
+			return result;
+		};
+
+		//food returns the initial state of the feed if the server provided it, otherwise calls feed.
+		s.feed.food = async (id, feed) => {
+			let feeds = seed.state ? seed.state().feeds : null;
+			if (feeds && id in feeds) {
+				let food = feeds[id];
+				delete feeds[id];
+				return food;
+			}
+			return await feed();
+		};`)
 	})
 }
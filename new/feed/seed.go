@@ -49,6 +49,14 @@ func food2Data(food Food, q js.Ctx) client.Value {
 		switch f := food.(type) {
 		case rpc:
 			return client.Call(f.f, f.args...)
+		case paginated:
+			return client.Call(f.f, append([]client.Value{
+				js.String{Value: js.NewValue(`(cursor || "")`)}, client.NewInt(f.size),
+			}, f.args...)...)
+		case live:
+			return client.Call(f.f, f.args...)
+		case nested:
+			return js.NewValue("s.feed.at(%v, %v, [])", f.parent.Data, f.path)
 		case client.Value:
 			return f
 		}
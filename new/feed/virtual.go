@@ -0,0 +1,57 @@
+package feed
+
+import (
+	"qlova.org/seed"
+	"qlova.org/seed/client"
+	"qlova.org/seed/use/css"
+	"qlova.org/seed/use/css/units"
+	"qlova.org/seed/use/html"
+	"qlova.org/seed/use/js"
+)
+
+type virtual struct {
+	enabled bool
+	height  string
+}
+
+//Virtualize only renders the items of the feed that are in, or near, the view of the feed, the elements of
+//the items are recycled as the feed is scrolled. The feed becomes scrollable and must be given a height.
+//Each item is rowHeight high, if rowHeight is nil then the items are measured as they are rendered.
+//Virtualized feeds recycle their items rather than reconciling them, so Key has no effect on them.
+func Virtualize(rowHeight units.Unit) seed.Option {
+	return seed.NewOption(func(c seed.Seed) {
+		var v = virtual{enabled: true}
+		if rowHeight != nil {
+			v.height = string(css.Measure(rowHeight).Rule())
+		}
+		c.Save(v)
+		c.With(
+			css.Set("overflow-y", "auto"),
+			css.Set("overflow-anchor", "none"),
+		)
+	})
+}
+
+//ScrollTo scrolls a virtualized feed so that the item at the given index is at the top of the feed.
+func (f *Feed) ScrollTo(index client.Int) client.Script {
+	return html.Element(f.feed).Run("scrollto", index)
+}
+
+//virtual returns the options of a virtualized feed, or null if the feed is not virtualized.
+func (f *Feed) virtual() js.Value {
+	var v virtual
+	f.feed.Load(&v)
+
+	if !v.enabled {
+		return js.Null()
+	}
+
+	var height = js.Null()
+	if v.height != "" {
+		height = js.NewString(v.height).GetValue()
+	}
+
+	return js.NewObject{
+		"height": height,
+	}.GetValue()
+}
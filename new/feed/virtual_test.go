@@ -0,0 +1,22 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+
+	"qlova.org/seed/use/css/units/px"
+)
+
+func TestVirtualize(t *testing.T) {
+	if options := With(nil).virtual().String(); options != "null" {
+		t.Fatal("expected feeds that are not virtualized to have null options, not", options)
+	}
+
+	if options := With(nil, Virtualize(nil)).virtual().String(); options != `{"height":null}` {
+		t.Fatal("expected items to be measured without a row height, not", options)
+	}
+
+	if options := With(nil, Virtualize(px.New(40))).virtual().String(); !strings.Contains(options, "40") {
+		t.Fatal(options)
+	}
+}
@@ -22,5 +22,10 @@ func Charset(charset string) seed.Seed {
 
 //Description returns an HTML meta element with description set to the given string.
 func Description(description string) seed.Seed {
-	return New(attr.Set("description", description))
+	return Key("description", description)
+}
+
+//Property returns an HTML meta element with the given property, ie. for Open Graph.
+func Property(property string, content string) seed.Seed {
+	return New(attr.Set("property", property), attr.Set("content", content))
 }
@@ -1,6 +1,7 @@
 package page
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 
@@ -12,6 +13,7 @@ import (
 	"qlova.org/seed/use/css"
 	"qlova.org/seed/use/css/units/percentage/of"
 	"qlova.org/seed/use/html"
+	"qlova.org/seed/use/html/attr"
 	"qlova.org/seed/use/js"
 )
 
@@ -28,10 +30,19 @@ func newHarvester(parent seed.Seed) harvester {
 	}
 }
 
+//starting is saved on the seed that sets the starting page.
+type starting struct {
+	page Page
+}
+
+//Set sets the starting page of the app.
 func Set(page Page) seed.Option {
-	return client.OnLoad(js.Script(func(q js.Ctx) {
-		fmt.Fprintf(q, `seed.StartingPage = "%v";`, ID(page))
-	}))
+	return seed.NewOption(func(c seed.Seed) {
+		c.Save(starting{page})
+		c.With(client.OnLoad(js.Script(func(q js.Ctx) {
+			fmt.Fprintf(q, `seed.StartingPage = "%v";`, ID(page))
+		})))
+	})
 }
 
 func AddPages(pages ...Page) seed.Option {
@@ -69,12 +80,57 @@ func add(page Page) seed.Option {
 			client.SetID(ID(page)),
 			html.SetID(html.ID(element)),
 		)
+
+		//The client converts the parameters of the path to the kinds of the arguments.
+		if kinds := Kinds(reflect.TypeOf(page)); kinds != nil {
+			encoded, _ := json.Marshal(kinds)
+			element.With(attr.Set("data-params", string(encoded)))
+		}
+		if hasViews(element) {
+			element.With(attr.Set("data-views", "true"))
+		}
+
+		var data html.Data
+		element.Load(&data)
+		if path := data.Attributes["data-path"]; path != "" {
+			paths.Store(reflect.TypeOf(page), path)
+		}
+		//Lazy pages are split into a chunk, that the client loads when the page is first visited.
+		var lazy lazy
+		if element.Load(&lazy) {
+			element.With(client.Chunk("/chunks/" + ID(page)[1:] + ".js"))
+
+			if lazy.idle {
+				template.With(client.OnLoad(js.Script(func(q js.Ctx) {
+					fmt.Fprintf(q, `(window.requestIdleCallback || setTimeout)(function() { seed.chunk.prefetch("%v"); });`, ID(page))
+				})))
+			}
+		}
+
 		element.Use()
+		element.Save(harvested{page, template})
 
 		element.AddTo(template)
 	})
 }
 
+//hasViews returns true if c has views with a path.
+func hasViews(c seed.Seed) bool {
+	var data html.Data
+	c.Load(&data)
+
+	if data.Attributes["data-view"] != "" {
+		return true
+	}
+
+	for _, child := range c.Children() {
+		if hasViews(child) {
+			return true
+		}
+	}
+	return false
+}
+
 func (h harvester) harvest(c seed.Seed) {
 	var data data
 	c.Load(&data)
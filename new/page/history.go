@@ -0,0 +1,259 @@
+package page
+
+import (
+	"qlova.org/seed"
+	"qlova.org/seed/client"
+	"qlova.org/seed/client/clientside"
+	"qlova.org/seed/use/html"
+	"qlova.org/seed/use/js"
+	"qlova.org/seed/use/js/window"
+)
+
+//Replace returns a script that goes to the given page, replacing the current entry
+//of the history instead of adding a new one, so that going back skips the current page.
+func (r Router) Replace(page Page) js.Script {
+	return func(q js.Ctx) {
+		page, args, path := parseArgs(page)
+
+		var data data
+		r.c.Load(&data)
+		data.pages = append(data.pages, page)
+		r.c.Save(data)
+
+		q.Run(js.Function{js.NewValue(`seed.goto`)}, js.NewString(ID(page)), args, path, js.NewBool(true))
+	}
+}
+
+//ClearHistory clears the back stack, so that the client cannot go back to the pages that
+//came before the current page, ie. after logging out.
+func ClearHistory() js.Script {
+	return js.Func("seed.history.clear").Run()
+}
+
+//CanGoBack is true when there is a page in the history to go back to.
+var CanGoBack = &clientside.Bool{
+	MemoryAddress: clientside.MemoryAddress{
+		Name: "page.history.back",
+	},
+}
+
+//ExitIfOption vetoes navigation away from a page.
+type ExitIfOption struct {
+	condition js.AnyBool
+	otherwise client.Script
+}
+
+//ExitIf only lets the client leave this page when the condition is true,
+//otherwise navigation away from the page is vetoed and the client stays on the page.
+func ExitIf(condition js.AnyBool) ExitIfOption {
+	return ExitIfOption{condition, nil}
+}
+
+//Else runs the given script whenever navigation is vetoed.
+func (e ExitIfOption) Else(do client.Script) seed.Option {
+	e.otherwise = do
+	return e
+}
+
+//AddTo implements seed.Option.
+func (e ExitIfOption) AddTo(c seed.Seed) {
+	var guard = js.NewObject{
+		"test": js.NewFunction(js.Return(e.condition)),
+	}
+
+	if e.otherwise != nil {
+		guard["otherwise"] = e.otherwise.GetScript().GetFunction()
+	}
+
+	guarded(c, guard)
+}
+
+//ConfirmExit asks the user to confirm leaving this page with the given message whenever unsaved is true,
+//ie. when a form has unsaved changes. The browser also asks the user before the app is closed or reloaded.
+func ConfirmExit(unsaved js.AnyBool, message client.String) seed.Option {
+	return seed.NewOption(func(c seed.Seed) {
+		guarded(c, js.NewObject{
+			"test":    js.NewFunction(js.Return(unsaved.GetBool().Not().Or(window.Confirm(message)))),
+			"unsaved": js.NewNormalFunction(js.Return(unsaved)),
+		})
+	})
+}
+
+//guarded adds a guard to the page, that is tested before the client leaves it.
+func guarded(c seed.Seed, guard js.NewObject) {
+	c.With(
+		client.OnLoad(client.NewScript(
+			html.Element(c).Set("guards", js.NewValue(`(%v || [])`, html.Element(c).Get("guards"))),
+			html.Element(c).Get("guards").Run("push", guard),
+		)),
+	)
+}
+
+func init() {
+	client.RegisterRenderer(func(c seed.Seed) []byte {
+		return []byte(`
+//seed.history manages the entries of the pages that have been visited, each entry
+//has a snapshot of the scroll positions and views of its page that is restored when
+//the client goes back (or forward) to it.
+seed.history = {};
+seed.history.epoch = +sessionStorage.getItem("*HistoryEpoch") || 0;
+seed.history.start = +sessionStorage.getItem("*HistoryStart") || 0;
+seed.history.index = (history.state && history.state.index) || 0;
+seed.history.current = null;
+seed.history.next = null;
+seed.history.vetoed = false;
+seed.history.ignore = false;
+seed.history.stack = [];
+seed.history.snapshots = {};
+
+if ("scrollRestoration" in history) history.scrollRestoration = "manual";
+
+//seed.history.record adds an entry for the page that has just been visited, or replaces the current entry.
+seed.history.record = function(id, args, url, title, href, replace) {
+	if (seed.goto.back && seed.history.next) {
+		seed.history.current = seed.history.next;
+		seed.history.next = null;
+		if (!seed.production) history.replaceState(seed.history.current, title, href);
+		seed.history.changed();
+		return;
+	}
+
+	if (!seed.history.current) replace = true;
+
+	let entry = {
+		key: Date.now().toString(36) + Math.random().toString(36).slice(2),
+		page: id,
+		args: args || {},
+		url: url,
+		epoch: seed.history.epoch,
+		index: replace ? seed.history.index : seed.history.index + 1,
+	};
+	seed.history.index = entry.index;
+	seed.history.current = entry;
+
+	if (seed.production) {
+		if (replace) {
+			history.replaceState(entry, title, href);
+		} else {
+			history.pushState(entry, title, href);
+		}
+	} else {
+		if (replace) seed.history.stack.pop();
+		seed.history.stack.push(entry);
+		history.replaceState(entry, title, href);
+	}
+
+	seed.history.changed();
+};
+
+//seed.history.changed persists the position in the history and updates page.CanGoBack.
+seed.history.changed = function() {
+	sessionStorage.setItem("*HistoryEpoch", seed.history.epoch);
+	sessionStorage.setItem("*HistoryStart", seed.history.start);
+
+	let back = seed.production ? seed.history.index > seed.history.start : seed.history.stack.length > 1;
+	if (q.setvar) q.setvar("page.history.back", "", back);
+};
+
+//seed.history.save takes a snapshot of the scroll positions and views of the current page, before it is left.
+seed.history.save = function() {
+	let entry = seed.history.current;
+	let page = seed.CurrentPage;
+	if (!entry || !page || page == seed.LoadingPage) return;
+
+	let snapshot = {scroll: [], views: {}};
+	if (page.scrollTop || page.scrollLeft) snapshot.scroll.push([-1, page.scrollLeft, page.scrollTop]);
+
+	let all = page.querySelectorAll("*");
+	for (let i = 0; i < all.length; i++) {
+		if (all[i].scrollTop || all[i].scrollLeft) snapshot.scroll.push([i, all[i].scrollLeft, all[i].scrollTop]);
+	}
+
+	for (let template of page.querySelectorAll("template")) {
+		let of = template.parentElement;
+		if (of.id && of.CurrentView && of.CurrentName) snapshot.views[of.id] = [of.CurrentName, of.CurrentView.args || {}];
+	}
+
+	seed.history.snapshots[entry.key] = snapshot;
+};
+
+//seed.history.restore restores the snapshot of the entry, after its page has been returned to.
+seed.history.restore = async function(entry) {
+	let snapshot = entry && seed.history.snapshots[entry.key];
+	let page = seed.CurrentPage;
+	if (!snapshot || !page) return;
+
+	if (seed.view) for (let id in snapshot.views) {
+		let of = q.get(id);
+		if (of && page.contains(of)) await seed.view(of, snapshot.views[id][0], snapshot.views[id][1]);
+	}
+
+	let all = page.querySelectorAll("*");
+	for (let [i, left, top] of snapshot.scroll) {
+		let element = (i < 0) ? page : all[i];
+		if (element) {
+			element.scrollLeft = left;
+			element.scrollTop = top;
+		}
+	}
+};
+
+//seed.history.go moves through the history to the entry, restoring its snapshot.
+//If navigation is vetoed, the client returns to the entry that it was on.
+seed.history.go = async function(entry, revert) {
+	seed.history.vetoed = false;
+	seed.history.next = entry;
+
+	seed.goto.back = true;
+	await seed.goto(entry.page, entry.args, entry.url);
+	seed.goto.back = false;
+	seed.history.next = null;
+
+	if (seed.history.vetoed) {
+		seed.history.vetoed = false;
+		revert();
+		return false;
+	}
+
+	seed.history.current = entry;
+	seed.history.changed();
+
+	await seed.history.restore(entry);
+	return true;
+};
+
+//seed.history.clear clears the back stack, the entries before the current entry can no longer be returned to.
+seed.history.clear = function() {
+	seed.history.epoch++;
+	seed.history.start = seed.history.index;
+	seed.history.snapshots = {};
+
+	let entry = seed.history.current;
+	if (entry) {
+		entry.epoch = seed.history.epoch;
+		if (seed.production) {
+			history.replaceState(entry, document.title);
+		} else {
+			seed.history.stack = [entry];
+		}
+	}
+
+	seed.history.changed();
+};
+
+//Pages with unsaved changes ask the user before the app is closed.
+window.addEventListener("beforeunload", function(event) {
+	let page = seed.CurrentPage;
+	if (!page || !page.guards) return;
+
+	for (let guard of page.guards) {
+		if (guard.unsaved && guard.unsaved()) {
+			event.preventDefault();
+			event.returnValue = "";
+			return "";
+		}
+	}
+});
+`)
+	})
+}
@@ -1,8 +1,12 @@
 package page
 
 import (
+	"fmt"
+
 	"qlova.org/seed"
+	"qlova.org/seed/client"
 	"qlova.org/seed/use/html/attr"
+	"qlova.org/seed/use/js"
 )
 
 //SetTitle sets the title of this page.
@@ -14,3 +18,37 @@ func SetTitle(title string) seed.Option {
 func SetPath(path string) seed.Option {
 	return attr.Set("data-path", path)
 }
+
+//lazy is saved on pages that are loaded on demand.
+type lazy struct {
+	idle bool
+}
+
+//Lazy loads this page on demand, its html, styles and scripts are left out of the document and are
+//fetched as a separate chunk when the page is first visited.
+func Lazy() seed.Option {
+	return seed.NewOption(func(c seed.Seed) {
+		var data lazy
+		c.Load(&data)
+		c.Save(data)
+	})
+}
+
+//PrefetchWhenIdle lazily loads this page as soon as the client is idle, rather than when it is first visited.
+func PrefetchWhenIdle() seed.Option {
+	return seed.NewOption(func(c seed.Seed) {
+		c.Save(lazy{idle: true})
+	})
+}
+
+//Prefetch loads the chunk of the given lazy page whenever this seed is hovered or focused,
+//so that the page is ready by the time it is pressed.
+func Prefetch(p Page) seed.Option {
+	var prefetch = js.Script(func(q js.Ctx) {
+		fmt.Fprintf(q, `seed.chunk.prefetch("%v");`, ID(p))
+	})
+	return seed.Options{
+		client.On("pointerenter", prefetch),
+		client.On("focus", prefetch),
+	}
+}
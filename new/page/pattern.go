@@ -0,0 +1,175 @@
+package page
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"qlova.org/seed/use/js"
+)
+
+//isPattern returns true if the path is a route pattern, rather than a plain path.
+func isPattern(path string) bool {
+	return strings.ContainsAny(path, "{*")
+}
+
+//matchPattern matches the url path against a route pattern, returning the values of its parameters.
+//A segment in braces matches any single segment, ie. "/projects/{id}/tasks/{task}", and a trailing "*" matches
+//the rest of the path. If prefix is true, the pattern only needs to match the start of the path and the rest is returned.
+func matchPattern(pattern, path string, prefix bool) (params map[string]string, rest string, ok bool) {
+	var patterns = split(pattern)
+	var segments = split(path)
+
+	params = make(map[string]string)
+	for i, p := range patterns {
+		if p == "*" && i == len(patterns)-1 {
+			return params, "", true
+		}
+		if i >= len(segments) {
+			return nil, "", false
+		}
+
+		var segment = segments[i]
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			value, err := url.PathUnescape(segment)
+			if err != nil {
+				return nil, "", false
+			}
+			params[p[1:len(p)-1]] = value
+			continue
+		}
+		if p != segment {
+			return nil, "", false
+		}
+	}
+
+	if len(segments) > len(patterns) {
+		if !prefix {
+			return nil, "", false
+		}
+		rest = "/" + strings.Join(segments[len(patterns):], "/")
+	}
+
+	return params, rest, true
+}
+
+//split returns the non-empty segments of a path.
+func split(path string) []string {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+//Kinds returns the kinds of the url arguments of a page or view that are not strings, by their url tag.
+//The kind is either "number" or "bool", so that the client can convert the parameters of a path.
+func Kinds(T reflect.Type) map[string]string {
+	if T.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var kinds map[string]string
+	for i := 0; i < T.NumField(); i++ {
+		var field = T.Field(i)
+
+		tag, ok := field.Tag.Lookup("url")
+		if !ok {
+			continue
+		}
+
+		var kind string
+		switch {
+		case field.Type == reflect.TypeOf((*js.AnyBool)(nil)).Elem():
+			kind = "bool"
+		case field.Type.Kind() == reflect.Interface && hasMethod(field.Type, "GetNumber"):
+			kind = "number"
+		default:
+			continue
+		}
+
+		if kinds == nil {
+			kinds = make(map[string]string)
+		}
+		kinds[tag] = kind
+	}
+	return kinds
+}
+
+func hasMethod(T reflect.Type, name string) bool {
+	_, ok := T.MethodByName(name)
+	return ok
+}
+
+//typed converts a parameter of a path to its kind.
+func typed(value string, kind string) interface{} {
+	switch kind {
+	case "number":
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return number
+		}
+	case "bool":
+		return value == "true"
+	}
+	return value
+}
+
+//Params are the url arguments of a page, by their url tag.
+type Params map[string]interface{}
+
+//paths are the url paths of the harvested pages, by their type.
+var paths sync.Map
+
+//URL returns the url of the page for the given parameters, so that pages can be linked to from Go, ie.
+//	page.URL(TaskPage{}, page.Params{"id": 3, "task": 4}) //"/projects/3/tasks/4"
+//The page must have been harvested and have a path. Parameters that are not part of the path are added to the query.
+func URL(p Page, params Params) (string, error) {
+	path, ok := paths.Load(reflect.TypeOf(p))
+	if !ok {
+		return "", fmt.Errorf("page %v has no path", ID(p))
+	}
+	return Reverse(path.(string), params)
+}
+
+//Reverse fills the parameters of the route pattern, any remaining parameters
+//are added to the query, except for "1" which follows the path.
+func Reverse(pattern string, params Params) (string, error) {
+	var used = make(map[string]bool)
+
+	var segments = strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			var key = segment[1 : len(segment)-1]
+			value, ok := params[key]
+			if !ok {
+				return "", fmt.Errorf("missing parameter %v of %v", key, pattern)
+			}
+			segments[i] = url.PathEscape(fmt.Sprint(value))
+			used[key] = true
+		}
+	}
+
+	var path = strings.Join(segments, "/")
+
+	if value, ok := params["1"]; ok && !used["1"] {
+		path = strings.TrimSuffix(path, "/") + "/" + url.PathEscape(fmt.Sprint(value))
+		used["1"] = true
+	}
+
+	var query = make(url.Values)
+	for key, value := range params {
+		if !used[key] {
+			query.Set(key, fmt.Sprint(value))
+		}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	return path, nil
+}
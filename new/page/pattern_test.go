@@ -0,0 +1,26 @@
+package page
+
+import "testing"
+
+func TestPattern(t *testing.T) {
+	params, rest, ok := matchPattern("/projects/{id}/tasks/{task}", "/projects/3/tasks/a%20b/comments", true)
+	if !ok || params["id"] != "3" || params["task"] != "a b" || rest != "/comments" {
+		t.Fatal(params, rest, ok)
+	}
+
+	if _, _, ok := matchPattern("/projects/{id}", "/projects/3/tasks", false); ok {
+		t.Fatal("matched a longer path")
+	}
+	if _, _, ok := matchPattern("/projects/{id}", "/projects", false); ok {
+		t.Fatal("matched a shorter path")
+	}
+
+	url, err := Reverse("/projects/{id}/tasks/{task}", Params{"id": 3, "task": "a b", "tab": "x"})
+	if err != nil || url != "/projects/3/tasks/a%20b?tab=x" {
+		t.Fatal(url, err)
+	}
+
+	if _, err := Reverse("/projects/{id}", nil); err == nil {
+		t.Fatal("expected a missing parameter")
+	}
+}
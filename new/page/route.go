@@ -0,0 +1,236 @@
+package page
+
+import (
+	"bytes"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"qlova.org/seed"
+	"qlova.org/seed/assets"
+	"qlova.org/seed/client"
+	"qlova.org/seed/use/html"
+	"qlova.org/seed/use/html/attr"
+)
+
+//Meta describes a page to search engines and link previews.
+type Meta struct {
+	Title, Description string
+
+	//Image is the path or url of the link preview image.
+	Image string
+}
+
+//Describer is implemented by pages that are described for each request,
+//ie. pages with url arguments that describe the content at the requested path.
+type Describer interface {
+	Page
+
+	Describe(client.Request) Meta
+}
+
+//SetDescription sets the description of this page, for search engines and link previews.
+func SetDescription(description string) seed.Option {
+	return attr.Set("data-description", description)
+}
+
+//SetImage sets the link preview image of this page.
+func SetImage(src string) seed.Option {
+	return attr.Set("data-image", assets.Path(src))
+}
+
+//harvested is saved on the element of each harvested page.
+type harvested struct {
+	page     Page
+	template seed.Seed
+}
+
+//Route is a harvested page.
+type Route struct {
+	Page Page
+
+	//Path is the url path of the page, empty if the page has no path.
+	Path string
+
+	Meta Meta
+
+	//Args is true when the page takes url path arguments,
+	//so that it is also routed from the paths below its own.
+	Args bool
+
+	//Views is true when the page has views with a path, which follow the path of the page.
+	Views bool
+
+	//Chunk is the path of the chunk that the page is loaded from, if the page is lazy.
+	Chunk string
+
+	template, element seed.Seed
+}
+
+//Routes returns the pages that have been harvested into c, ordered by their path.
+//The starting page is routed from "/" unless it has its own path.
+func Routes(c seed.Seed) []Route {
+	var routes []Route
+	collect(c, &routes)
+
+	if start := startingPage(c); start != nil {
+		for i, route := range routes {
+			if route.Path == "" && reflect.TypeOf(route.Page) == reflect.TypeOf(start) {
+				routes[i].Path = "/"
+				paths.LoadOrStore(reflect.TypeOf(start), "/")
+			}
+		}
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Path < routes[j].Path
+	})
+
+	return routes
+}
+
+func collect(c seed.Seed, routes *[]Route) {
+	var page harvested
+	c.Load(&page)
+
+	if page.page != nil {
+		var data html.Data
+		c.Load(&data)
+
+		var route = Route{
+			Page: page.page,
+			Path: data.Attributes["data-path"],
+			Meta: Meta{
+				Title:       data.Attributes["data-title"],
+				Description: data.Attributes["data-description"],
+				Image:       data.Attributes["data-image"],
+			},
+			Views:    data.Attributes["data-views"] != "",
+			template: page.template,
+			element:  c,
+		}
+
+		if T := reflect.TypeOf(page.page); T.Kind() == reflect.Struct {
+			for i := 0; i < T.NumField(); i++ {
+				if T.Field(i).Tag.Get("url") == "1" {
+					route.Args = true
+				}
+			}
+		}
+
+		route.Chunk, _ = client.ChunkOf(c)
+
+		*routes = append(*routes, route)
+	}
+
+	for _, child := range c.Children() {
+		collect(child, routes)
+	}
+}
+
+//startingPage returns the starting page set within c, if any.
+func startingPage(c seed.Seed) Page {
+	var data starting
+	c.Load(&data)
+
+	if data.page != nil {
+		return data.page
+	}
+
+	for _, child := range c.Children() {
+		if page := startingPage(child); page != nil {
+			return page
+		}
+	}
+
+	return nil
+}
+
+//Match returns true if the url path is routed to this page.
+func (r Route) Match(path string) bool {
+	if r.Path == "" {
+		return false
+	}
+	if isPattern(r.Path) {
+		_, _, ok := matchPattern(r.Path, path, r.Views)
+		return ok
+	}
+	if path == r.Path {
+		return true
+	}
+	if !r.Args && !r.Views {
+		return false
+	}
+	if r.Path == "/" {
+		return len(path) > 1
+	}
+	return len(path) > len(r.Path) && path[:len(r.Path)] == r.Path && path[len(r.Path)] == '/'
+}
+
+//Parse returns the arguments of the page for the url path and raw query that it matches, the same way that
+//the client parses them, along with the part of the url that follows the path of the page and the path of its views.
+func (r Route) Parse(path, query string) (args map[string]interface{}, rest, views string) {
+	args = make(map[string]interface{})
+
+	var kinds = Kinds(reflect.TypeOf(r.Page))
+
+	switch {
+	case isPattern(r.Path):
+		var params map[string]string
+		params, views, _ = matchPattern(r.Path, path, r.Views)
+		for key, value := range params {
+			args[key] = typed(value, kinds[key])
+		}
+
+	case r.Views:
+		views = strings.TrimPrefix(strings.TrimPrefix(path, r.Path), "/")
+		if views != "" {
+			views = "/" + views
+		}
+
+	default:
+		rest = strings.TrimPrefix(path, r.Path)
+		for i, segment := range split(rest) {
+			if value, err := url.PathUnescape(segment); err == nil {
+				args[strconv.Itoa(i+1)] = typed(value, kinds[strconv.Itoa(i+1)])
+			}
+		}
+	}
+
+	if values, err := url.ParseQuery(query); err == nil && len(values) > 0 {
+		for key, value := range values {
+			switch last := value[len(value)-1]; last {
+			case "true":
+				args[key] = true
+			case "false":
+				args[key] = false
+			case "undefined":
+				args[key] = nil
+			default:
+				args[key] = last
+			}
+		}
+		rest += "?" + query
+	}
+
+	return args, rest, views
+}
+
+//Prerender returns the rendered document with the page moved out of its template,
+//so that the page is visible before any scripts have run.
+//The client returns the page to its template when it starts routing.
+//Lazy pages are prerendered along with the html of their chunk.
+func (r Route) Prerender(document []byte) []byte {
+	var template = html.Render(r.template)
+	var element = html.Render(r.element)
+
+	var emptied = bytes.Replace(template, element, nil, 1)
+
+	if r.Chunk != "" {
+		element = html.RenderChunk(r.element)
+	}
+
+	return bytes.Replace(document, template, append(emptied, element...), 1)
+}
@@ -24,7 +24,8 @@ seed.CurrentPage = null;
 seed.NextPage = null;
 seed.LastPage = null;
 
-seed.goto = async function(id, args, url) {
+//seed.goto goes to the page with the given id, a new entry is added to the history unless replace is true.
+seed.goto = async function(id, args, url, replace) {
 	if(!url) url = "";
 
 	if (!id) {
@@ -44,6 +45,17 @@ seed.goto = async function(id, args, url) {
 		return;
 	}
 
+	//Lazy pages are loaded the first time that they are visited.
+	if (seed.NextPage.dataset.chunk) {
+		try {
+			await seed.chunk(seed.NextPage);
+		} catch(e) {
+			seed.NextPage = null;
+			seed.report(e);
+			return false;
+		}
+	}
+
 	var Refresh = false;
 	//If we are going to the same page then return.
 	if (seed.CurrentPage == seed.NextPage) {
@@ -56,6 +68,19 @@ seed.goto = async function(id, args, url) {
 		Refresh = true;
 	}
 
+	//Pages can veto navigation away from them, ie. to confirm unsaved changes.
+	if (!Refresh && seed.CurrentPage && seed.CurrentPage.guards) {
+		for (let guard of seed.CurrentPage.guards) {
+			if (!(await guard.test())) {
+				seed.NextPage = null;
+				seed.history.vetoed = true;
+
+				if (guard.otherwise) await guard.otherwise();
+				return false;
+			}
+		}
+	}
+
 	seed.NextPage.args = args || {};
 
 	//Check page conditions.
@@ -74,6 +99,8 @@ seed.goto = async function(id, args, url) {
 		}
 	}
 
+	seed.history.save();
+
 	if (window.flipping) flipping.read();
 
 	if (seed.NextPage.parent) {
@@ -120,6 +147,10 @@ seed.goto = async function(id, args, url) {
 	if (!data.path) {
 		path = "/";
 	}
+	if (path.includes("{")) {
+		path = seed.goto.reverse(path, args || {}, url);
+		url = "";
+	}
 
 	//Persistence.
 	localStorage.setItem('*CurrentPage', id);
@@ -128,17 +159,15 @@ seed.goto = async function(id, args, url) {
 	localStorage.setItem('*CurrentPath', path);
 	localStorage.setItem('*CurrentSearch', url);
 
-	if (!seed.goto.back && seed.production) history.pushState([id, args], data.title, path+url);
-	if (!seed.production) {
-		history.replaceState([id, args, url], data.title, path+url);
-		seed.goto.history.push([id, args, url]);
-	}
+	seed.history.record(id, args, url, data.title, path+url, replace);
 	if (data.title) {
 		document.title = data.title;
 	} else {
 		document.title = seed.title;
 	}
 
+	if (seed.view && seed.view.restore) seed.view.restore(seed.CurrentPage);
+
 	for (let promise of promises) {
 		await promise;
 	}
@@ -165,12 +194,13 @@ seed.goto = async function(id, args, url) {
 
 seed.back = async function() {
 	if (!seed.production) {
-		seed.goto.history.pop();
-		let state = seed.goto.history.pop();
+		let stack = seed.history.stack;
+		if (stack.length < 2) return;
 
-		seed.goto.back = true;
-		await seed.goto.apply(null, state);
-		seed.goto.back = false;
+		let left = stack.pop();
+		await seed.history.go(stack[stack.length-1], function() {
+			stack.push(left);
+		});
 	} else {
 		history.back();
 	}
@@ -183,24 +213,116 @@ window.addEventListener('popstate', async function (event) {
 		return;
 	}
 
-	if (event.state == null) {
+	//The client is returning to the entry that it was on.
+	if (seed.history.ignore) {
+		seed.history.ignore = false;
+		return;
+	}
+
+	let entry = event.state;
+	let index = seed.history.index;
+
+	let revert = function() {
+		seed.history.ignore = true;
+		history.go(index - entry.index);
+	};
+
+	if (entry == null || entry.page == null) {
 		window.history.forward();
 		return;
 	}
 
-	seed.goto.back = true;
-	await seed.goto.apply(null, event.state);
-	seed.goto.back = false;
+	//The back stack has been cleared.
+	if (entry.epoch != seed.history.epoch) {
+		revert();
+		return;
+	}
+
+	seed.history.index = entry.index;
+	if (!(await seed.history.go(entry, revert))) seed.history.index = index;
 });
 };
 
+//Matches the url path against a route pattern, returning the arguments and the rest of the path, or null.
+//When prefix is true, the pattern only needs to match the start of the path.
+seed.goto.match = function(pattern, path, types, prefix) {
+	let patterns = pattern.split("/").filter(Boolean);
+	let segments = path.split("/").filter(Boolean);
+
+	let args = {};
+	for (let i = 0; i < patterns.length; i++) {
+		let p = patterns[i];
+		if (p == "*" && i == patterns.length-1) return {args: args, rest: ""};
+		if (i >= segments.length) return null;
+
+		if (p[0] == "{" && p[p.length-1] == "}") {
+			let key = p.slice(1, -1);
+			args[key] = seed.goto.typed(decodeURIComponent(segments[i]), types && types[key]);
+		} else if (p != segments[i]) {
+			return null;
+		}
+	}
+
+	let rest = segments.slice(patterns.length);
+	if (rest.length && !prefix) return null;
+
+	return {args: args, rest: rest.length ? "/" + rest.join("/") : ""};
+};
+
+//Converts a parameter of a path to the kind of its argument.
+seed.goto.typed = function(value, kind) {
+	if (kind == "number") {
+		let number = Number(value);
+		return isNaN(number) ? value : number;
+	}
+	if (kind == "bool") return value == "true";
+	return value;
+};
+
+//Fills the parameters of a route pattern with the arguments, the query of the url keeps the arguments that are left.
+seed.goto.reverse = function(pattern, args, url) {
+	let used = {};
+	let path = pattern.replace(/\{([^}]+)\}/g, function(match, key) {
+		used[key] = true;
+		return encodeURIComponent(args[key]);
+	});
+
+	let query = new URLSearchParams((url || "").split("?")[1] || "");
+	for (let key in used) query.delete(key);
+	query = query.toString();
+
+	return path + (query ? "?" + query : "");
+};
+
 seed.goto.queue = [];
 seed.goto.back = false;
-seed.goto.history = [];
 
 seed.goto.ready = async function() {
 	if (!seed.goto) return;
 
+	//Prerendered pages are returned to their templates, so that they are routed like any other page.
+	for (let page of document.querySelectorAll("template + .page")) {
+		let template = page.previousElementSibling;
+		template.content.appendChild(page);
+		page.parent = template;
+		page.prerendered = true;
+	}
+
+	//The server passes the route of deep links and missing pages, so that they are shown directly.
+	let route = document.getElementById("seed.route");
+	if (route) {
+		route.parentElement.removeChild(route);
+		route = JSON.parse(route.textContent);
+
+		let href = location.pathname + location.search + location.hash;
+		if (await seed.goto(route.page, route.args, route.url)) {
+			//Missing pages keep the url that was asked for.
+			if (route.missing) history.replaceState(history.state, document.title, href);
+			if (route.views && seed.view && seed.view.route) await seed.view.route(seed.CurrentPage, route.views);
+			return;
+		}
+	}
+
 	let saved_page = window.localStorage.getItem('*CurrentPage');
 	let saved_query = window.localStorage.getItem('*CurrentQuery');
 	let saved_path = window.localStorage.getItem('*CurrentPath');
@@ -240,6 +362,29 @@ seed.goto.ready = async function() {
 
 		for (let template of templates) {
 			element = template.content.querySelector(".page");
+			if (element && element.dataset.path) {
+				let pattern = element.dataset.path.includes("{");
+				let views = element.dataset.views && (pattern || path.startsWith(element.dataset.path));
+
+				//Route patterns and pages with views are matched by their segments.
+				if (pattern || views) {
+					let types = element.dataset.params ? JSON.parse(element.dataset.params) : null;
+					let match = seed.goto.match(element.dataset.path, path, types, !!element.dataset.views);
+					if (!match) continue;
+
+					let args = match.args;
+					new URLSearchParams(window.location.search).forEach(function(value, key) {
+						args[key] = (value == "true") ? true : (value == "false") ? false : (value == "undefined") ? null : value;
+					});
+
+					if (await seed.goto(element.id, args, window.location.search)) {
+						if (match.rest && seed.view && seed.view.route) await seed.view.route(seed.CurrentPage, match.rest);
+						return;
+					} else {
+						break;
+					}
+				}
+			}
 			if (element) {
 				if (element.dataset.path == path && window.location.search == "") {
 					if (await seed.goto(element.id, {})) {
@@ -0,0 +1,57 @@
+package popup
+
+import (
+	"fmt"
+	"reflect"
+
+	"qlova.org/seed"
+	"qlova.org/seed/client"
+	"qlova.org/seed/client/clientargs"
+	"qlova.org/seed/use/js"
+)
+
+//Ask shows the popup and waits until it is resolved from inside of the popup, see Resolve.
+//The result is typed by the Result field of the popup, ie. a popup with a Result field of
+//client.Bool returns a client.Bool. If the popup is hidden before it is resolved,
+//the script that asked is stopped. The popup is harvested from the seed of the script, ie.
+//
+//	client.OnClick(js.Script(func(q js.Ctx) {
+//		q.If(popup.Ask(q, Confirm{}).(client.Bool), Delete())
+//	}))
+func Ask(q js.Ctx, p Popup) client.Value {
+	popup, args := parseArgs(p)
+
+	q(seed.Mutate(func(d *data) {
+		if d.popups == nil {
+			d.popups = make(map[reflect.Type]Popup)
+		}
+
+		d.popups[reflect.TypeOf(p)] = popup
+	}))
+
+	var result = js.NewValue(`(await seed.ask(%v, %v))`, js.NewString(ID(p)), args)
+
+	if T := reflect.TypeOf(p); T.Kind() == reflect.Struct {
+		if field, ok := T.FieldByName("Result"); ok && field.Type != reflect.TypeOf([0]client.Value{}).Elem() {
+			if typed := clientargs.ValueAs(result, field.Type); typed.IsValid() && !typed.IsZero() {
+				return typed.Interface().(client.Value)
+			}
+		}
+	}
+
+	return result
+}
+
+//Resolve resolves the popup that this script is running inside of with the given value, and then hides it.
+func Resolve(value client.Value) js.Script {
+	return func(q js.Ctx) {
+		fmt.Fprintf(q, `await seed.resolve(seed.active, %v);`, value.GetValue())
+	}
+}
+
+//Dismiss hides the popup that this script is running inside of, without resolving it.
+func Dismiss() js.Script {
+	return func(q js.Ctx) {
+		q(`await seed.dismiss(seed.active);`)
+	}
+}
@@ -77,6 +77,7 @@ func (h harvester) harvest(c seed.Seed) {
 //This should normally only be called by app-level runtime packages such as seed/app.
 func Harvest() seed.Option {
 	return seed.NewOption(func(c seed.Seed) {
-		newHarvester(c).harvest(c)
+		var h = newHarvester(c)
+		h.harvest(c)
 	})
 }
@@ -0,0 +1,163 @@
+package popup
+
+import (
+	"strings"
+
+	"qlova.org/seed"
+	"qlova.org/seed/client"
+	"qlova.org/seed/use/html/attr"
+)
+
+//Dismissal is a way that the user can dismiss a popup.
+type Dismissal int
+
+//Dismissals, they can be combined, ie. Escape|Backdrop.
+const (
+	//Escape dismisses the popup when the Escape key is pressed.
+	Escape Dismissal = 1 << iota
+
+	//Backdrop dismisses the popup when the area around its content is pressed.
+	Backdrop
+)
+
+//SetDismissal sets the ways that the user can dismiss this popup, ie. SetDismissal(Escape|Backdrop).
+//By default a popup can only be hidden by its own controls.
+func SetDismissal(d Dismissal) seed.Option {
+	var ways []string
+	if d&Escape != 0 {
+		ways = append(ways, "escape")
+	}
+	if d&Backdrop != 0 {
+		ways = append(ways, "backdrop")
+	}
+	return attr.Set("data-dismiss", strings.Join(ways, " "))
+}
+
+func init() {
+	client.RegisterRenderer(func(c seed.Seed) []byte {
+		return []byte(`
+//seed.modal tracks the stack of open popups, the last popup is on top of the others.
+//Whilst a popup is open, focus is trapped inside of it and the page underneath does not scroll.
+seed.modal = {stack: [], blocked: null};
+
+//seed.modal.open adds the popup to the top of the stack, busy popups cannot be dismissed.
+seed.modal.open = function(popup, busy) {
+	let stack = seed.modal.stack;
+
+	let i = stack.indexOf(popup);
+	if (i >= 0) {
+		stack.splice(i, 1);
+	} else {
+		popup.opener = document.activeElement;
+	}
+
+	if (popup.layer == null) popup.layer = parseInt(getComputedStyle(popup).zIndex) || 1;
+	popup.busy = !!busy;
+
+	stack.push(popup);
+	popup.style.zIndex = popup.layer + stack.length - 1;
+	seed.CurrentPopup = popup;
+
+	if (!popup.modal) {
+		popup.modal = true;
+		popup.addEventListener("click", function(event) {
+			if (event.target == popup && seed.modal.dismissible(popup, "backdrop")) seed.hide(popup.id);
+		});
+	}
+
+	seed.modal.block();
+
+	if (popup.focus) popup.focus({preventScroll: true});
+};
+
+//seed.modal.close removes the popup from the stack and returns focus to the element that opened it.
+seed.modal.close = function(popup) {
+	let stack = seed.modal.stack;
+
+	let i = stack.indexOf(popup);
+	if (i < 0) return;
+	stack.splice(i, 1);
+
+	seed.CurrentPopup = stack[stack.length-1] || null;
+
+	let opener = popup.opener;
+	popup.opener = null;
+	if (opener && opener.focus && document.contains(opener)) opener.focus({preventScroll: true});
+
+	if (!stack.length) seed.modal.unblock();
+};
+
+//seed.modal.dismissible returns true if the popup can be dismissed in the given way.
+seed.modal.dismissible = function(popup, way) {
+	if (popup.busy) return false;
+	return (popup.dataset.dismiss || "").split(" ").includes(way);
+};
+
+//seed.modal.focusable returns the elements inside of the popup that can be focused.
+seed.modal.focusable = function(popup) {
+	let elements = popup.querySelectorAll('a[href], button, input, select, textarea, [tabindex], [contenteditable]');
+	return Array.prototype.filter.call(elements, function(element) {
+		return !element.disabled && element.tabIndex >= 0 && element.getClientRects().length > 0;
+	});
+};
+
+//seed.modal.block stops the page underneath the popups from scrolling and hides it from assistive technologies.
+seed.modal.block = function() {
+	let page = seed.CurrentPage;
+	if (seed.modal.blocked || !page) return;
+
+	seed.modal.blocked = {page: page, overflow: page.style.overflow};
+	page.style.overflow = "hidden";
+	page.setAttribute("aria-hidden", "true");
+};
+
+seed.modal.unblock = function() {
+	let blocked = seed.modal.blocked;
+	if (!blocked) return;
+
+	blocked.page.style.overflow = blocked.overflow;
+	blocked.page.removeAttribute("aria-hidden");
+	seed.modal.blocked = null;
+};
+
+document.addEventListener("keydown", function(event) {
+	let popup = seed.modal.stack[seed.modal.stack.length-1];
+	if (!popup) return;
+
+	if (event.key == "Escape") {
+		if (seed.modal.dismissible(popup, "escape")) {
+			event.preventDefault();
+			seed.hide(popup.id);
+		}
+		return;
+	}
+
+	//Keep the focus inside of the popup on top.
+	if (event.key == "Tab") {
+		let focusable = seed.modal.focusable(popup);
+		if (!focusable.length) {
+			event.preventDefault();
+			popup.focus();
+			return;
+		}
+
+		let first = focusable[0], last = focusable[focusable.length-1];
+		let active = document.activeElement;
+
+		if (!popup.contains(active) || (event.shiftKey && (active == first || active == popup))) {
+			event.preventDefault();
+			(event.shiftKey ? last : first).focus();
+		} else if (!event.shiftKey && active == last) {
+			event.preventDefault();
+			first.focus();
+		}
+	}
+});
+
+document.addEventListener("focusin", function(event) {
+	let popup = seed.modal.stack[seed.modal.stack.length-1];
+	if (popup && !popup.contains(event.target)) popup.focus({preventScroll: true});
+});
+`)
+	})
+}
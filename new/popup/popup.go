@@ -13,6 +13,7 @@ import (
 	"qlova.org/seed/use/css"
 	"qlova.org/seed/use/css/units/percentage/of"
 	"qlova.org/seed/use/html"
+	"qlova.org/seed/use/html/attr"
 	"qlova.org/seed/use/js"
 )
 
@@ -37,6 +38,10 @@ func New(options ...seed.Option) seed.Seed {
 
 		transition.SetOnEnter(OnShow),
 		transition.SetOnExit(OnHide),
+
+		attr.Set("role", "dialog"),
+		attr.Set("aria-modal", "true"),
+		attr.Set("tabindex", "-1"),
 	)
 
 	for _, option := range options {
@@ -83,7 +88,7 @@ func Wrap(p Popup, s ...client.Script) js.Script {
 			d.popups[reflect.TypeOf(p)] = popup
 		}))
 
-		fmt.Fprintf(q, `seed.show("%v", %v); try {`, ID(p), args.GetObject().String())
+		fmt.Fprintf(q, `seed.show("%v", %v, true); try {`, ID(p), args.GetObject().String())
 		client.NewScript(s...).GetScript()(q)
 		fmt.Fprintf(q, `seed.hide("%[1]v"); } catch(e) { seed.hide("%[1]v"); throw e;  }`, ID(p))
 	}
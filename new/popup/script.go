@@ -11,7 +11,8 @@ func init() {
 
 seed.CurrentPopup = null;
 
-seed.show = async function(id, args) {
+//seed.show shows the popup on top of any other popups, busy popups cannot be dismissed by the user.
+seed.show = async function(id, args, busy) {
 	let popup = q.get(id);
 	if (!popup) {
 		console.error("seed.show: invalid popup ", id);
@@ -21,8 +22,8 @@ seed.show = async function(id, args) {
 
 	popup.parent.parentElement.appendChild(popup);
 
-	seed.CurrentPopup = popup;
 	popup.args = args;
+	seed.modal.open(popup, busy);
 
 	if (popup.onshow) await popup.onshow();
 
@@ -70,9 +71,58 @@ seed.hide = async function(id) {
 		await promise;
 	}
 
-	seed.CurrentPopup = null;
-
 	popup.template.content.appendChild(popup);
+	seed.modal.close(popup);
+
+	//Popups that are hidden before they are resolved stop the script that asked for them.
+	if (popup.asked) {
+		let asked = popup.asked;
+		popup.asked = null;
+		asked.reject("");
+	}
+};
+
+//seed.ask shows the popup and returns a promise of the value that the popup is resolved with.
+seed.ask = function(id, args) {
+	let popup = q.get(id);
+	if (!popup) return Promise.reject("seed.ask: invalid popup " + id);
+
+	if (popup.asked) popup.asked.reject("");
+
+	let promise = new Promise(function(resolve, reject) {
+		popup.asked = {resolve: resolve, reject: reject};
+	});
+
+	seed.show(id, args).catch(function(e) {
+		if (popup.asked) popup.asked.reject(e);
+	});
+
+	return promise;
+};
+
+//seed.popup returns the asked popup that the element is inside of.
+seed.popup = function(element) {
+	while (element && !element.asked) element = element.parentElement || element.parent;
+	if (!element && seed.CurrentPopup && seed.CurrentPopup.asked) element = seed.CurrentPopup;
+	return element;
+};
+
+//seed.resolve resolves the popup that the element is inside of, with the given value.
+seed.resolve = async function(element, value) {
+	let popup = seed.popup(element);
+	if (!popup) return;
+
+	let asked = popup.asked;
+	popup.asked = null;
+
+	await seed.hide(popup.id);
+	asked.resolve(value);
+};
+
+//seed.dismiss hides the popup that the element is inside of, without resolving it.
+seed.dismiss = async function(element) {
+	let popup = seed.popup(element);
+	if (popup) await seed.hide(popup.id);
 };`)
 	})
 }
@@ -130,6 +130,24 @@ func (text Text) String() string {
 	return result
 }
 
+//escape escapes plain text as HTML, preserving whitespace.
+func escape(s string) string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	s = html.EscapeString(s)
+	s = strings.Replace(s, "\n", "<br>", -1)
+	s = strings.Replace(s, "  ", "&nbsp;&nbsp;", -1)
+	s = strings.Replace(s, "\t", "&emsp;", -1)
+
+	if s[len(s)-1] == ' ' {
+		s = s[:len(s)-1] + "&nbsp;"
+	}
+
+	return s
+}
+
 //HTML returns the text formatted as HTML.
 func (text Text) HTML() string {
 
@@ -140,21 +158,12 @@ func (text Text) HTML() string {
 		}
 
 		if s[0] > 1 {
-			s := html.EscapeString(string(s))
-			s = strings.Replace(s, "\n", "<br>", -1)
-			s = strings.Replace(s, "  ", "&nbsp;&nbsp;", -1)
-			s = strings.Replace(s, "\t", "&emsp;", -1)
-
-			if s[len(s)-1] == ' ' {
-				s = s[:len(s)-1] + "&nbsp;"
-			}
-
-			return s
+			return escape(s)
 		}
 
 		switch s[1] {
 		case reset:
-			return s[2:]
+			return escape(s[2:])
 		case italic:
 			return "<em>" + convert(s[2:]) + "</em>"
 		case bold:
@@ -164,11 +173,11 @@ func (text Text) HTML() string {
 		case rgba:
 			return "<span style='color:#" + s[2:10] + ";'>" + convert(s[10:]) + "</span>"
 		case icon:
-			return "<img style='margin-top: 0.1em;vertical-align:text-top;height:1em;font-size:inherit;' src='" + s[3:3+int(s[2])] + "'>" + convert(s[3+int(s[2]):])
+			return "<img style='margin-top: 0.1em;vertical-align:text-top;height:1em;font-size:inherit;' src='" + html.EscapeString(s[3:3+int(s[2])]) + "'>" + convert(s[3+int(s[2]):])
 		case link:
 			url := s[4 : 4+int(s[2])]
 			label := convert(s[4+int(s[2]) : 4+int(s[2])+int(s[3])])
-			return "<a href='" + url + "'>" + label + "</a>"
+			return "<a href='" + html.EscapeString(url) + "'>" + label + "</a>"
 		default:
 			panic("invalid text format")
 		}
@@ -55,6 +55,9 @@ func parseArgs(view View, parent seed.Seed) (View, js.AnyObject) {
 			if intf := FieldValue.Interface(); intf != nil {
 
 				var key = Field.Name
+				if tag, ok := Field.Tag.Lookup("url"); ok {
+					key = tag
+				}
 
 				object[key] = intf.(js.AnyValue)
 
@@ -0,0 +1,32 @@
+package view
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"qlova.org/seed"
+	"qlova.org/seed/new/page"
+	"qlova.org/seed/use/html/attr"
+)
+
+//SetPath sets the url path pattern of this view, which follows the path of its page, ie.
+//	view.SetPath("/tasks/{task}")
+//binds the segment after "/tasks/" to the argument of the view with the `url:"task"` tag.
+//The url of the page reflects the current view and deep links to the page open the view.
+func SetPath(pattern string) seed.Option {
+	return attr.Set("data-path", pattern)
+}
+
+//paths are the url path patterns of the views, by their type.
+var paths sync.Map
+
+//URL returns the url path of the view for the given parameters, to be added to the url of its page, ie.
+//	page.URL(ProjectPage{}, page.Params{"id": 3}) + view.URL(TaskView{}, page.Params{"task": 4})
+func URL(v View, params page.Params) (string, error) {
+	path, ok := paths.Load(reflect.TypeOf(v))
+	if !ok {
+		return "", fmt.Errorf("view %v has no path", Name(v))
+	}
+	return page.Reverse(path.(string), params)
+}
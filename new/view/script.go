@@ -31,6 +31,7 @@ seed.view = async function(of, name, args, fragment) {
 		of.NextView = null;
 		of.CurrentView.args = args;
 		of.CurrentView.onviewenter();
+		seed.view.reflect(of);
 		return;
 	}
 
@@ -100,6 +101,8 @@ seed.view = async function(of, name, args, fragment) {
 
 	if (window.flipping) flipping.flip();
 
+	seed.view.reflect(of);
+
 	//Persistence.
 	localStorage.setItem(of.id+'.CurrentView', of.CurrentView.id);
 	localStorage.setItem(of.id+'.LastViewTime', Date.now());
@@ -115,6 +118,69 @@ seed.view = async function(of, name, args, fragment) {
 	}
 }
 
+//Reflects the path of the current view of the controller in the url, after the path of its page.
+seed.view.reflect = function(of) {
+	if (!seed.goto || !seed.goto.reverse) return;
+	if (!seed.CurrentPage || !seed.CurrentPage.contains(of)) return;
+
+	let view = of.CurrentView;
+	let fragment = "";
+	if (view && view.dataset.path) fragment = seed.goto.reverse(view.dataset.path, view.args || {}, "");
+
+	let path = location.pathname;
+	if (of.view.fragment) {
+		let i = path.lastIndexOf(of.view.fragment);
+		if (i >= 0) path = path.slice(0, i);
+	}
+	of.view.fragment = fragment;
+
+	if (path + fragment != location.pathname) {
+		history.replaceState(history.state, document.title, path + fragment + location.search);
+	}
+}
+
+//Reflects the current views of the page in the url, after the page has been entered.
+seed.view.restore = function(page) {
+	let controllers = [];
+	for (let template of page.querySelectorAll("template")) {
+		let of = template.parentElement;
+		if (of.view && of.CurrentView && controllers.indexOf(of) < 0) controllers.push(of);
+	}
+	for (let of of controllers) {
+		of.view.fragment = "";
+		seed.view.reflect(of);
+	}
+}
+
+//Routes the views of the page from the rest of the url path that follows the path of the page.
+seed.view.route = async function(page, rest) {
+	while (rest) {
+		let found = null;
+
+		for (let template of page.querySelectorAll("template")) {
+			let of = template.parentElement;
+			let view = template.content.children[0];
+			if (!view && of.CurrentView && of.CurrentView.template == template) view = of.CurrentView;
+			if (!view || !view.dataset.view) continue;
+
+			let types = view.dataset.params ? JSON.parse(view.dataset.params) : null;
+			let match = seed.goto.match(view.dataset.path, rest, types, true);
+			if (match) {
+				found = {of: of, view: view, match: match};
+				break;
+			}
+		}
+
+		if (!found) return;
+
+		if (!found.of.view) found.of.view = {};
+		found.of.view.fragment = rest.slice(0, rest.length - found.match.rest.length);
+		await seed.view(found.of, found.view.dataset.view, found.match.args);
+
+		rest = found.match.rest;
+	}
+}
+
 seed.view.ready = async function(of, id, args) {
 	of.StartingView = id;
 	if (!seed.view) return;
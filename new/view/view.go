@@ -2,17 +2,20 @@
 package view
 
 import (
+	"encoding/json"
 	"reflect"
 	"strings"
 
 	"qlova.org/seed"
 	"qlova.org/seed/client"
 	"qlova.org/seed/client/clientside"
+	"qlova.org/seed/new/page"
 	"qlova.org/seed/set"
 	"qlova.org/seed/set/transition"
 	"qlova.org/seed/use/css"
 	"qlova.org/seed/use/css/units/percentage/of"
 	"qlova.org/seed/use/html"
+	"qlova.org/seed/use/html/attr"
 	"qlova.org/seed/use/js"
 )
 
@@ -70,6 +73,19 @@ func (c Controller) Goto(view View) js.Script {
 		element.With(
 			html.AddClass(Name(view)),
 		)
+
+		//Views with a path are routed from the url of their page.
+		var htmlData html.Data
+		element.Load(&htmlData)
+		if path := htmlData.Attributes["data-path"]; path != "" {
+			element.With(attr.Set("data-view", Name(view)))
+			if kinds := page.Kinds(reflect.TypeOf(view)); kinds != nil {
+				encoded, _ := json.Marshal(kinds)
+				element.With(attr.Set("data-params", string(encoded)))
+			}
+			paths.Store(reflect.TypeOf(view), path)
+		}
+
 		element.Use()
 		element.AddTo(template)
 	}
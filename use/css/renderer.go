@@ -6,6 +6,7 @@ import (
 	"sort"
 
 	"qlova.org/seed"
+	"qlova.org/seed/client"
 )
 
 type Renderer func(root seed.Seed) []byte
@@ -17,6 +18,15 @@ func RegisterRenderer(r Renderer) {
 }
 
 func render(c seed.Seed, tracker map[string]struct{}) []byte {
+	//Chunks are rendered on their own, see RenderChunk.
+	if _, ok := client.ChunkOf(c); ok {
+		return nil
+	}
+	return rendered(c, tracker)
+}
+
+//rendered renders the css of a seed and its children, even if the seed is a chunk.
+func rendered(c seed.Seed, tracker map[string]struct{}) []byte {
 	var b bytes.Buffer
 	var data data
 	c.Load(&data)
@@ -86,3 +96,9 @@ func Render(root seed.Seed) []byte {
 
 	return b.Bytes()
 }
+
+//RenderChunk renders the css of the seed of a chunk and its children, see client.Chunk.
+//Chunks within the chunk are left to be rendered on their own.
+func RenderChunk(c seed.Seed) []byte {
+	return rendered(c, make(map[string]struct{}))
+}
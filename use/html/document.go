@@ -2,6 +2,7 @@ package html
 
 import (
 	"bytes"
+	"io"
 
 	"qlova.org/seed"
 )
@@ -25,9 +26,14 @@ func New() Document {
 
 func (doc Document) Render() []byte {
 	var b bytes.Buffer
-
-	b.WriteString("<!DOCTYPE html>")
-	b.Write(Render(doc.Seed))
-
+	doc.RenderTo(&b)
 	return b.Bytes()
 }
+
+//RenderTo streams the document to the writer.
+func (doc Document) RenderTo(w io.Writer) error {
+	if _, err := io.WriteString(w, "<!DOCTYPE html>"); err != nil {
+		return err
+	}
+	return RenderTo(w, doc.Seed)
+}
@@ -1,9 +1,9 @@
 package html
 
 import (
+	"encoding/json"
 	"fmt"
 	"html"
-	"strconv"
 	"strings"
 
 	"qlova.org/seed"
@@ -26,6 +26,13 @@ type Data struct {
 	Attributes map[string]string
 }
 
+//quote returns s as a javascript string literal that is safe to embed in an inline script,
+//'<', '>' and '&' are escaped so that user data cannot close the script.
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
 //SetID returns an option that sets the HTML id associated with the seed.
 func SetID(id string) seed.Option {
 	return seed.NewOption(func(c seed.Seed) {
@@ -34,10 +41,10 @@ func SetID(id string) seed.Option {
 
 		switch mode, q := client.Seed(c); mode {
 		case client.AddTo:
-			fmt.Fprintf(q, `%v.id = %v;`, client.Element(c), strconv.Quote(id))
+			fmt.Fprintf(q, `%v.id = %v;`, client.Element(c), quote(id))
 		case client.Undo:
 			if data.ID != nil {
-				fmt.Fprintf(q, `%v.id = %v;`, client.Element(c), strconv.Quote(*data.ID))
+				fmt.Fprintf(q, `%v.id = %v;`, client.Element(c), quote(*data.ID))
 			} else {
 				fmt.Fprintf(q, `%v.id = %v;`, client.Element(c), c.ID())
 			}
@@ -57,9 +64,9 @@ func AddClass(class string) seed.Option {
 
 		switch mode, q := client.Seed(c); mode {
 		case client.AddTo:
-			fmt.Fprintf(q, `%v.classList.With(%v);`, client.Element(c), strconv.Quote(class))
+			fmt.Fprintf(q, `%v.classList.With(%v);`, client.Element(c), quote(class))
 		case client.Undo:
-			fmt.Fprintf(q, `%v.classList.remove(%v);`, client.Element(c), strconv.Quote(class))
+			fmt.Fprintf(q, `%v.classList.remove(%v);`, client.Element(c), quote(class))
 		default:
 
 			for _, existing := range data.Classes {
@@ -103,9 +110,9 @@ func Set(html string) seed.Option {
 
 		switch mode, q := client.Seed(c); mode {
 		case client.AddTo:
-			fmt.Fprintf(q, `%v.innerHTML = %v;`, client.Element(c), strconv.Quote(html))
+			fmt.Fprintf(q, `%v.innerHTML = %v;`, client.Element(c), quote(html))
 		case client.Undo:
-			fmt.Fprintf(q, `%v.innerHTML = %v;`, client.Element(c), strconv.Quote(data.InnerHTML))
+			fmt.Fprintf(q, `%v.innerHTML = %v;`, client.Element(c), quote(data.InnerHTML))
 		default:
 			data.InnerHTML = html
 		}
@@ -122,12 +129,12 @@ func SetAttribute(name string, constant string) seed.Option {
 
 		switch mode, q := client.Seed(c); mode {
 		case client.AddTo:
-			fmt.Fprintf(q, `%v.setAttribute(%v, %v);`, client.Element(c), strconv.Quote(name), strconv.Quote(constant))
+			fmt.Fprintf(q, `%v.setAttribute(%v, %v);`, client.Element(c), quote(name), quote(constant))
 		case client.Undo:
 			if attr, ok := data.Attributes[name]; ok {
-				fmt.Fprintf(q, `%v.setAttribute(%v, %v);`, client.Element(c), strconv.Quote(name), strconv.Quote(attr))
+				fmt.Fprintf(q, `%v.setAttribute(%v, %v);`, client.Element(c), quote(name), quote(attr))
 			} else {
-				fmt.Fprintf(q, `%v.removeAttribute(%v);`, client.Element(c), strconv.Quote(name))
+				fmt.Fprintf(q, `%v.removeAttribute(%v);`, client.Element(c), quote(name))
 			}
 		default:
 			if data.Attributes == nil {
@@ -181,9 +188,9 @@ func SetInnerText(constant string) seed.Option {
 
 		switch mode, q := client.Seed(c); mode {
 		case client.AddTo:
-			fmt.Fprintf(q, `%v.innerText = %v;`, client.Element(c), strconv.Quote(constant))
+			fmt.Fprintf(q, `%v.innerText = %v;`, client.Element(c), quote(constant))
 		case client.Undo:
-			fmt.Fprintf(q, `%v.innerHTML = %v;`, client.Element(c), strconv.Quote(data.InnerHTML))
+			fmt.Fprintf(q, `%v.innerHTML = %v;`, client.Element(c), quote(data.InnerHTML))
 		default:
 			data.InnerHTML = html.EscapeString(constant)
 			data.InnerHTML = strings.Replace(data.InnerHTML, "\n", "<br>", -1)
@@ -2,9 +2,9 @@ package html
 
 import (
 	"bytes"
-	"fmt"
+	"html"
+	"io"
 	"sort"
-	"strconv"
 
 	"qlova.org/seed"
 	"qlova.org/seed/client"
@@ -18,23 +18,71 @@ func ID(root seed.Seed) string {
 //Render renders the html of a seed.
 func Render(c seed.Seed) []byte {
 	var b bytes.Buffer
+	RenderTo(&b, c)
+	return b.Bytes()
+}
+
+//RenderTo streams the html of a seed to the writer, returning the first write error.
+func RenderTo(w io.Writer, c seed.Seed) error {
+	var r = renderer{w: w}
+	r.render(c)
+	return r.err
+}
+
+//RenderChunk renders the html of a seed that has been split into a chunk, along with its children.
+//Chunks within the chunk are still left out, see client.Chunk.
+func RenderChunk(c seed.Seed) []byte {
+	var b bytes.Buffer
+	var r = renderer{w: &b, chunk: c.ID()}
+	r.render(c)
+	return b.Bytes()
+}
+
+//renderer writes html and remembers the first write error.
+type renderer struct {
+	w   io.Writer
+	err error
+
+	//chunk is the ID of the chunk whose children are rendered.
+	chunk int
+}
+
+func (r *renderer) write(strings ...string) {
+	for _, s := range strings {
+		if r.err != nil {
+			return
+		}
+		_, r.err = io.WriteString(r.w, s)
+	}
+}
+
+//attribute writes an attribute with an escaped value.
+func (r *renderer) attribute(name, value string) {
+	r.write(" ", name, `="`, html.EscapeString(value), `"`)
+}
+
+func (r *renderer) render(c seed.Seed) {
 	var data Data
 
 	c.Load(&data)
 
 	if data.Tag != "" {
-		fmt.Fprintf(&b, `<%v`, data.Tag)
+		r.write("<", data.Tag)
 
 		if c.Used() {
 			if data.ID != nil {
 				if *data.ID != "" {
-					fmt.Fprintf(&b, ` id=%v`, strconv.Quote(*data.ID))
+					r.attribute("id", *data.ID)
 				}
 			} else {
-				fmt.Fprintf(&b, ` id=%v`, ID(c))
+				r.attribute("id", ID(c))
 			}
 		}
 
+		if path, ok := client.ChunkOf(c); ok {
+			r.attribute("data-chunk", path)
+		}
+
 		if data.Attributes != nil {
 
 			//Deterministic render.
@@ -45,24 +93,26 @@ func Render(c seed.Seed) []byte {
 			sort.Strings(keys)
 
 			for _, property := range keys {
-				value := data.Attributes[property]
-				fmt.Fprintf(&b, " %v=%v ", property, strconv.Quote(value))
+				r.attribute(property, data.Attributes[property])
 			}
 		}
 
 		if data.Classes != nil {
-			fmt.Fprint(&b, ` class="`)
-			for _, class := range data.Classes {
-				fmt.Fprintf(&b, " %v ", class)
+			r.write(` class="`)
+			for i, class := range data.Classes {
+				if i > 0 {
+					r.write(" ")
+				}
+				r.write(html.EscapeString(class))
 			}
-			fmt.Fprint(&b, `" `)
+			r.write(`"`)
 		}
 
 		mode, _ := client.Seed(c)
 		ok := mode == client.AddTo
 
 		if data.Style != nil || ok {
-			fmt.Fprint(&b, ` style="`)
+			r.write(` style="`)
 
 			//Deterministic render.
 			keys := make([]string, 0, len(data.Style))
@@ -72,27 +122,27 @@ func Render(c seed.Seed) []byte {
 			sort.Strings(keys)
 
 			for _, property := range keys {
-				value := data.Style[property]
-				fmt.Fprintf(&b, "%v: %v;", property, value)
+				r.write(html.EscapeString(property), ": ", html.EscapeString(data.Style[property]), ";")
 			}
 			if ok {
-				fmt.Fprint(&b, `display: none;`)
+				r.write(`display: none;`)
 			}
-			fmt.Fprint(&b, `" `)
+			r.write(`"`)
 		}
 
-		fmt.Fprint(&b, ">")
+		r.write(">")
 
-		b.WriteString(data.InnerHTML)
+		r.write(data.InnerHTML)
 	}
 
-	for _, child := range c.Children() {
-		b.Write(Render(child))
+	//The children of chunks are loaded on demand.
+	if _, ok := client.ChunkOf(c); !ok || c.ID() == r.chunk {
+		for _, child := range c.Children() {
+			r.render(child)
+		}
 	}
 
 	if data.Tag != "" {
-		fmt.Fprintf(&b, `</%v>`, data.Tag)
+		r.write("</", data.Tag, ">")
 	}
-
-	return b.Bytes()
 }
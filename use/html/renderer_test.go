@@ -0,0 +1,54 @@
+package html_test
+
+import (
+	"bytes"
+	"testing"
+
+	"qlova.org/seed"
+	"qlova.org/seed/client"
+	"qlova.org/seed/use/html"
+)
+
+func TestRenderEscapes(t *testing.T) {
+	var c = seed.New(
+		html.SetTag("div"),
+		html.SetID(`a"b`),
+		html.SetAttribute("title", `"><script>alert(1)</script>`),
+		html.AddClass(`x"y`),
+		html.SetStyle("font-family", `"Open Sans" & <b>`),
+	)
+	c.Use()
+
+	const expected = `<div id="a&#34;b" title="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;" class="x&#34;y" style="font-family: &#34;Open Sans&#34; &amp; &lt;b&gt;;"></div>`
+
+	if rendered := string(html.Render(c)); rendered != expected {
+		t.Fatalf("expected\n%v\ngot\n%v", expected, rendered)
+	}
+
+	var b bytes.Buffer
+	if err := html.RenderTo(&b, c); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != expected {
+		t.Fatalf("RenderTo does not match Render: %v", b.String())
+	}
+}
+
+func TestRenderChunk(t *testing.T) {
+	var c = seed.New(
+		html.SetTag("div"),
+		html.SetID("page"),
+		client.Chunk("/chunks/page.js"),
+
+		seed.New(html.SetTag("span"), html.Set("content")),
+	)
+	c.Use()
+
+	if rendered := string(html.Render(c)); rendered != `<div id="page" data-chunk="/chunks/page.js"></div>` {
+		t.Fatalf("the children of a chunk should not be rendered: %v", rendered)
+	}
+
+	if rendered := string(html.RenderChunk(c)); rendered != `<div id="page" data-chunk="/chunks/page.js"><span>content</span></div>` {
+		t.Fatalf("the children of a chunk should be rendered with the chunk: %v", rendered)
+	}
+}
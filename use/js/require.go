@@ -45,3 +45,10 @@ func Scripts(root seed.Seed) map[string]string {
 	scripts(root, result)
 	return result
 }
+
+//Required returns the external scripts required by this seed, without those of its children.
+func Required(c seed.Seed) map[string]string {
+	var data data
+	c.Load(&data)
+	return data.requires
+}